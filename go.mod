@@ -2,4 +2,7 @@ module github.com/liuyehcf/common-gtools
 
 go 1.13
 
-require github.com/robfig/cron/v3 v3.0.0
+require (
+	github.com/robfig/cron/v3 v3.0.0
+	gopkg.in/yaml.v2 v2.4.0
+)
@@ -1,13 +1,49 @@
 package log
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/liuyehcf/common-gtools/utils"
 	"io"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// default capacity of the queue of events waiting to be written
+	DefaultQueueSize = 1024
+
+	// block the caller until there is room in the queue
+	OverflowPolicyBlock = "block"
+
+	// drop the event being appended if the queue is full
+	OverflowPolicyDropNewest = "dropNewest"
+
+	// drop the earliest queued event to make room if the queue is full
+	OverflowPolicyDropOldest = "dropOldest"
+
+	// drop events below WARN once the queue reaches the discarding threshold, and block for the others if the queue is full
+	OverflowPolicyDiscardBelowWarn = "discardBelowWarn"
+)
+
+const (
+	// created but events are not accepted yet
+	appenderCreated = iota
+
+	// accepting events, and writing them in event loop
+	appenderStarted
+
+	// events are not accepted any more, the queued events are still written
+	appenderStopped
 )
 
 type AppenderConfig struct {
-	// layout
+	// encoder of logging event, EncoderPattern by default
+	Encoder string
+
+	// layout, only used for EncoderPattern
 	Layout string
 
 	// filters of log
@@ -19,6 +55,21 @@ type AppenderConfig struct {
 
 	// only used for fileAppender
 	FileRollingPolicy *RollingPolicy
+
+	// only used for syslogAppender
+	Syslog *SyslogConfig
+
+	// only used for socketAppender, events are sent without encoding, so Encoder and Layout are ignored
+	Socket *SocketConfig
+
+	// capacity of the queue of events waiting to be written, DefaultQueueSize by default
+	QueueSize int
+
+	// what to do if the queue is full, OverflowPolicyBlock by default
+	OverflowPolicy string
+
+	// only supported by OverflowPolicyDiscardBelowWarn, 80% of QueueSize(at least 1) by default
+	DiscardingThreshold int
 }
 
 type Appender interface {
@@ -29,33 +80,164 @@ type Appender interface {
 	Destroy()
 }
 
+// implemented by the builtin appenders, which are started once created
+type Lifecycle interface {
+	// start accepting events, it is a no-op if already started, and fails if already stopped
+	Start() error
+
+	// stop accepting events, the queued events are still written, and resources are released after that
+	// it is safe to be called repeatedly or concurrently with DoAppend
+	Stop()
+
+	IsStarted() bool
+}
+
+// implemented by the builtin appenders, which write events asynchronously
+type flushableAppender interface {
+	Appender
+
+	// wait until the accepted events are written and synced, return false if ctx is done before that
+	flush(ctx context.Context) bool
+
+	// wait until the event loop exits after Destroy, return false if ctx is done before that
+	waitStopped(ctx context.Context) bool
+
+	// number of events which are accepted but not written yet
+	getPendingCount() int64
+}
+
 type abstractAppender struct {
-	filters     []Filter
-	encoder     encoder
-	lock        *sync.Mutex
-	queue       chan []byte
-	isDestroyed bool
+	// number of events which are accepted but not written yet
+	// keep the counters the first fields to guarantee 64-bit alignment for atomic operations
+	pending int64
+
+	// number of events dropped by overflow policy
+	dropped int64
+
+	filters             []Filter
+	encoder             encoder
+	lock                *sync.Mutex
+	queue               chan []byte
+	overflowPolicy      string
+	discardingThreshold int
+
+	// guards state, DoAppend holds the read lock while sending, so that the queue is never closed meanwhile
+	stateLock *sync.RWMutex
+	state     int
+
+	// closed at the beginning of stopping, to wake up the callers blocked on the full queue
+	stopping  chan struct{}
+	closeOnce *sync.Once
+
+	// closed when the event loop exits
+	stopped chan struct{}
+}
+
+func newAbstractAppender(config *AppenderConfig, encoder encoder) (abstractAppender, error) {
+	queueSize := config.QueueSize
+	if queueSize == 0 {
+		queueSize = DefaultQueueSize
+	}
+	if queueSize < 0 {
+		return abstractAppender{}, errors.New("QueueSize must not be negative")
+	}
+
+	overflowPolicy := config.OverflowPolicy
+	switch overflowPolicy {
+	case emptyString:
+		overflowPolicy = OverflowPolicyBlock
+	case OverflowPolicyBlock, OverflowPolicyDropNewest, OverflowPolicyDropOldest, OverflowPolicyDiscardBelowWarn:
+	default:
+		return abstractAppender{}, fmt.Errorf("unsupported overflow policy '%s'", overflowPolicy)
+	}
+
+	if config.DiscardingThreshold != 0 && overflowPolicy != OverflowPolicyDiscardBelowWarn {
+		return abstractAppender{}, errors.New("DiscardingThreshold is only supported by OverflowPolicyDiscardBelowWarn")
+	}
+	discardingThreshold := config.DiscardingThreshold
+	if discardingThreshold == 0 {
+		discardingThreshold = queueSize * 4 / 5
+
+		// otherwise events below WARN are discarded even if the tiny queue is empty
+		if discardingThreshold < 1 {
+			discardingThreshold = 1
+		}
+	}
+	if discardingThreshold < 0 || discardingThreshold > queueSize {
+		return abstractAppender{}, errors.New("DiscardingThreshold must be between 0 and QueueSize")
+	}
+
+	return abstractAppender{
+		filters:             config.Filters,
+		encoder:             encoder,
+		lock:                new(sync.Mutex),
+		queue:               make(chan []byte, queueSize),
+		overflowPolicy:      overflowPolicy,
+		discardingThreshold: discardingThreshold,
+		stateLock:           new(sync.RWMutex),
+		state:               appenderCreated,
+		stopping:            make(chan struct{}),
+		closeOnce:           new(sync.Once),
+		stopped:             make(chan struct{}),
+	}, nil
+}
+
+// run onStart if the appender is not started yet
+func (appender *abstractAppender) start(onStart func()) error {
+	appender.stateLock.Lock()
+	defer appender.stateLock.Unlock()
+
+	switch appender.state {
+	case appenderStarted:
+		return nil
+	case appenderStopped:
+		return errors.New("appender is stopped, and can not be restarted")
+	}
+
+	appender.state = appenderStarted
+	onStart()
+	return nil
+}
+
+// close the queue if the appender is not stopped yet, eventLoop is run here if the appender is never started,
+// so that the resources are released in the same way
+func (appender *abstractAppender) stop(eventLoop func()) bool {
+	appender.closeOnce.Do(func() {
+		close(appender.stopping)
+	})
+
+	appender.stateLock.Lock()
+	defer appender.stateLock.Unlock()
+
+	if appender.state == appenderStopped {
+		return false
+	}
+
+	isStarted := appender.state == appenderStarted
+	appender.state = appenderStopped
+	close(appender.queue)
+	if !isStarted {
+		go eventLoop()
+	}
+	return true
+}
+
+func (appender *abstractAppender) IsStarted() bool {
+	appender.stateLock.RLock()
+	defer appender.stateLock.RUnlock()
+	return appender.state == appenderStarted
 }
 
 func (appender *abstractAppender) DoAppend(event *LoggingEvent) {
-	// pre check is done here to avoid performing defer when the channel is closed, so as to improve performance
-	if appender.isDestroyed {
+	// the queue is closed with the write lock held, so it is open as long as the read lock is held
+	appender.stateLock.RLock()
+	defer appender.stateLock.RUnlock()
+
+	if appender.state != appenderStarted {
 		return
 	}
 
-	// although we made the flag bit judgment in the previous step,
-	// this does not guarantee that the channel must be in normal state when sending data,
-	// because it may be closed at this time.
-	// That is to say, when checking the flag bit, the channel is normal. When sending data, the channel is closed
-	//
-	// recover from a crash caused by sending a message to a closed channel
-	// it must be ensured that when the channel is closed, the flag 'isDestroyed' has been set to ensure the recovery of panic
-	// so the isDestroyed flag must be set before the channel is closed
-	defer appender.recoverIfChanClosed()
-	if appender.filters == nil {
-		// if channel is closed, then the upper statement will panic
-		appender.queue <- appender.encoder.encode(event)
-	} else {
+	if appender.filters != nil {
 		for _, filter := range appender.filters {
 			if utils.IsNotNil(filter) {
 				if !filter.Accept(event) {
@@ -63,15 +245,90 @@ func (appender *abstractAppender) DoAppend(event *LoggingEvent) {
 				}
 			}
 		}
-		// if channel is closed, then the upper statement will panic
-		appender.queue <- appender.encoder.encode(event)
+	}
+
+	// check before encoding, so that the discarded events cost as little as possible
+	if appender.overflowPolicy == OverflowPolicyDiscardBelowWarn && event.Level < WarnLevel &&
+		len(appender.queue) >= appender.discardingThreshold {
+		atomic.AddInt64(&appender.dropped, 1)
+		return
+	}
+
+	content := appender.encoder.encode(event)
+	atomic.AddInt64(&appender.pending, 1)
+	switch appender.overflowPolicy {
+	case OverflowPolicyDropNewest:
+		select {
+		case appender.queue <- content:
+		default:
+			appender.onDropped()
+		}
+	case OverflowPolicyDropOldest:
+		for {
+			select {
+			case appender.queue <- content:
+				return
+			default:
+			}
+
+			// the queue may be drained by event loop meanwhile, so it is not always dropped
+			select {
+			case _, ok := <-appender.queue:
+				if ok {
+					appender.onDropped()
+				}
+			default:
+			}
+		}
+	default:
+		select {
+		case appender.queue <- content:
+		case <-appender.stopping:
+			// the appender is being stopped, give up so that the read lock is released
+			atomic.AddInt64(&appender.pending, -1)
+		}
 	}
 }
 
-func (appender *abstractAppender) recoverIfChanClosed() {
-	if appender.isDestroyed {
-		recover()
+// get number of events dropped by overflow policy
+func (appender *abstractAppender) GetDroppedCount() int64 {
+	return atomic.LoadInt64(&appender.dropped)
+}
+
+// mark one pending event as dropped
+func (appender *abstractAppender) onDropped() {
+	atomic.AddInt64(&appender.pending, -1)
+	atomic.AddInt64(&appender.dropped, 1)
+}
+
+// mark one pending event as written
+func (appender *abstractAppender) onWritten() {
+	atomic.AddInt64(&appender.pending, -1)
+}
+
+// wait until all the accepted events are written, return false if ctx is done before that
+func (appender *abstractAppender) waitDrained(ctx context.Context) bool {
+	for atomic.LoadInt64(&appender.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Millisecond):
+		}
 	}
+	return true
+}
+
+func (appender *abstractAppender) waitStopped(ctx context.Context) bool {
+	select {
+	case <-appender.stopped:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (appender *abstractAppender) getPendingCount() int64 {
+	return atomic.LoadInt64(&appender.pending)
 }
 
 func executeIgnorePanic(f func()) {
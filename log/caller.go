@@ -0,0 +1,66 @@
+package log
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// start time of the process, printed by `%r` as milliseconds elapsed since it
+	startTime = time.Now()
+
+	processId = os.Getpid()
+	hostName  = getHostName()
+
+	// looking up goroutine id is expensive, so it is only done when some layout contains `%t`
+	isGoroutineIdRequired int32
+)
+
+// get the frame of the caller, skip is the same as runtime.Caller, 0 identifies the caller of getCallerFrame
+// a single program counter is resolved, so that function, file and line come from the same lookup
+func getCallerFrame(skip int) runtime.Frame {
+	pcs := make([]uintptr, 1)
+
+	// skip runtime.Callers and getCallerFrame itself
+	if runtime.Callers(skip+2, pcs) == 0 {
+		return runtime.Frame{}
+	}
+
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return frame
+}
+
+func requireGoroutineId() {
+	atomic.StoreInt32(&isGoroutineIdRequired, 1)
+}
+
+func getGoroutineIdIfRequired() int64 {
+	if atomic.LoadInt32(&isGoroutineIdRequired) == 0 {
+		return 0
+	}
+	return getGoroutineId()
+}
+
+// split function name like `github.com/liuyehcf/common-gtools/log.(*loggerImpl).Info`
+// into package `github.com/liuyehcf/common-gtools/log` and method `(*loggerImpl).Info`
+func splitFunctionName(function string) (string, string) {
+	lastSlash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[lastSlash+1:], ".")
+	if dot < 0 {
+		return emptyString, function
+	}
+
+	dot += lastSlash + 1
+	return function[:dot], function[dot+1:]
+}
+
+func getHostName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
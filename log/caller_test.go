@@ -0,0 +1,20 @@
+package log
+
+import (
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+)
+
+func TestSplitFunctionName(t *testing.T) {
+	pkg, method := splitFunctionName("github.com/liuyehcf/common-gtools/log.(*loggerImpl).Info")
+	utils.AssertTrue(pkg == "github.com/liuyehcf/common-gtools/log" && method == "(*loggerImpl).Info", "test")
+
+	pkg, method = splitFunctionName("github.com/a/b.Func.func1")
+	utils.AssertTrue(pkg == "github.com/a/b" && method == "Func.func1", "test")
+
+	pkg, method = splitFunctionName("main.main")
+	utils.AssertTrue(pkg == "main" && method == "main", "test")
+
+	pkg, method = splitFunctionName("")
+	utils.AssertTrue(pkg == "" && method == "", "test")
+}
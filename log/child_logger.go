@@ -0,0 +1,96 @@
+package log
+
+import (
+	"context"
+)
+
+// logger which can provide the target logger impl
+type targetProvider interface {
+	Logger
+
+	// get target logger impl, return nil if it is not available
+	getTarget() *loggerImpl
+}
+
+// child logger created by With or Ctx, the parent is usually a virtual logger
+// so the child logger will follow the parent after the target logger is created or replaced
+type childLogger struct {
+	parent targetProvider
+	ctx    context.Context
+	fields []Field
+}
+
+func newChildLogger(parent targetProvider, ctx context.Context, fields []Field) *childLogger {
+	return &childLogger{
+		parent: parent,
+		ctx:    ctx,
+		fields: fields,
+	}
+}
+
+func (logger *childLogger) Name() string {
+	return logger.parent.Name()
+}
+
+func (logger *childLogger) IsTraceEnabled() bool {
+	return logger.parent.IsTraceEnabled()
+}
+
+func (logger *childLogger) Trace(format string, values ...interface{}) {
+	logger.log(TraceLevel, format, values...)
+}
+
+func (logger *childLogger) IsDebugEnabled() bool {
+	return logger.parent.IsDebugEnabled()
+}
+
+func (logger *childLogger) Debug(format string, values ...interface{}) {
+	logger.log(DebugLevel, format, values...)
+}
+
+func (logger *childLogger) IsInfoEnabled() bool {
+	return logger.parent.IsInfoEnabled()
+}
+
+func (logger *childLogger) Info(format string, values ...interface{}) {
+	logger.log(InfoLevel, format, values...)
+}
+
+func (logger *childLogger) IsWarnEnabled() bool {
+	return logger.parent.IsWarnEnabled()
+}
+
+func (logger *childLogger) Warn(format string, values ...interface{}) {
+	logger.log(WarnLevel, format, values...)
+}
+
+func (logger *childLogger) IsErrorEnabled() bool {
+	return logger.parent.IsErrorEnabled()
+}
+
+func (logger *childLogger) Error(format string, values ...interface{}) {
+	logger.log(ErrorLevel, format, values...)
+}
+
+func (logger *childLogger) Level() int {
+	return logger.parent.Level()
+}
+
+func (logger *childLogger) With(keyValues ...interface{}) Logger {
+	return newChildLogger(logger.parent, logger.ctx, mergeFields(logger.fields, newFields(keyValues)))
+}
+
+func (logger *childLogger) Ctx(ctx context.Context) Logger {
+	return newChildLogger(logger.parent, ctx, logger.fields)
+}
+
+// must be called by the logging method directly
+// so that the caller depth is the same as virtual logger, i.e. user code -> childLogger -> log
+func (logger *childLogger) log(level int, format string, values ...interface{}) {
+	// target may be null if target logger is created or replaced
+	target := logger.parent.getTarget()
+	if target == nil || target.Level() > level {
+		return
+	}
+	target.callAllAppenders(defaultCallerSkip, level, logger.ctx, logger.fields, format, values...)
+}
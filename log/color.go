@@ -0,0 +1,61 @@
+package log
+
+const (
+	ansiEscape = "\x1b["
+	ansiReset  = ansiEscape + "0m"
+
+	blackCode   = "30"
+	redCode     = "31"
+	greenCode   = "32"
+	yellowCode  = "33"
+	blueCode    = "34"
+	magentaCode = "35"
+	cyanCode    = "36"
+	whiteCode   = "37"
+	grayCode    = "90"
+	boldCode    = "1"
+)
+
+var (
+	colorCodes = map[string]string{
+		"black":       blackCode,
+		"red":         redCode,
+		"green":       greenCode,
+		"yellow":      yellowCode,
+		"blue":        blueCode,
+		"magenta":     magentaCode,
+		"cyan":        cyanCode,
+		"white":       whiteCode,
+		"gray":        grayCode,
+		"bold":        boldCode,
+		"boldBlack":   boldCode + ";" + blackCode,
+		"boldRed":     boldCode + ";" + redCode,
+		"boldGreen":   boldCode + ";" + greenCode,
+		"boldYellow":  boldCode + ";" + yellowCode,
+		"boldBlue":    boldCode + ";" + blueCode,
+		"boldMagenta": boldCode + ";" + magentaCode,
+		"boldCyan":    boldCode + ";" + cyanCode,
+		"boldWhite":   boldCode + ";" + whiteCode,
+	}
+)
+
+// same as logback, ERROR is bold red, WARN is red, INFO is blue, and the others are left as is
+func getHighlightCode(level int) string {
+	switch level {
+	case ErrorLevel:
+		return boldCode + ";" + redCode
+	case WarnLevel:
+		return redCode
+	case InfoLevel:
+		return blueCode
+	}
+	return emptyString
+}
+
+// wrap content with ansi escape sequence, content is left as is if colour is disabled or code is empty
+func colorize(content string, code string, isColorEnabled bool) string {
+	if !isColorEnabled || code == emptyString {
+		return content
+	}
+	return ansiEscape + code + "m" + content + ansiReset
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"github.com/liuyehcf/common-gtools/utils"
+	"os"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	encoder, _ := newPatternEncoder("%highlight(%-5p) %red([%c]) %boldRed(%m)%n", true)
+	event := &LoggingEvent{
+		Name:    "color",
+		Level:   ErrorLevel,
+		Message: "hello",
+	}
+
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "\x1b[1;31mERROR\x1b[0m \x1b[31m[color]\x1b[0m \x1b[1;31mhello\x1b[0m\n", content)
+
+	event.Level = InfoLevel
+	content = string(encoder.encode(event))
+	utils.AssertTrue(content == "\x1b[34mINFO \x1b[0m \x1b[31m[color]\x1b[0m \x1b[1;31mhello\x1b[0m\n", content)
+
+	event.Level = DebugLevel
+	content = string(encoder.encode(event))
+	utils.AssertTrue(content == "DEBUG \x1b[31m[color]\x1b[0m \x1b[1;31mhello\x1b[0m\n", content)
+}
+
+func TestNestedColor(t *testing.T) {
+	encoder, _ := newPatternEncoder("%-8green(%p) %green(%p%cyan(%c)) (%m)", true)
+	event := &LoggingEvent{
+		Name:    "ab",
+		Level:   WarnLevel,
+		Message: "hello",
+	}
+
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "\x1b[32mWARN    \x1b[0m \x1b[32mWARN\x1b[36mab\x1b[0m\x1b[0m (hello)", content)
+
+	encoder, _ = newPatternEncoder("%.-2red(%p)", true)
+	content = string(encoder.encode(event))
+	utils.AssertTrue(content == "\x1b[31mWA\x1b[0m", content)
+}
+
+func TestColorDisabled(t *testing.T) {
+	encoder, _ := newPatternEncoder("%highlight(%-5p) %red([%c]) %m%n", false)
+	event := &LoggingEvent{
+		Name:    "color",
+		Level:   ErrorLevel,
+		Message: "hello",
+	}
+
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "ERROR [color] hello\n", content)
+
+	file, _ := os.Create("/tmp/gtools_color_test.log")
+	defer os.Remove(file.Name())
+	defer file.Close()
+	utils.AssertFalse(isTerminal(file), "test")
+	utils.AssertFalse(isTerminal(NewStringWriter(nil)), "test")
+}
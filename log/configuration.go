@@ -0,0 +1,988 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liuyehcf/common-gtools/utils"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	appenderTypeConsole = "console"
+	appenderTypeFile    = "file"
+	appenderTypeSyslog  = "syslog"
+	appenderTypeSocket  = "socket"
+	consoleTargetStdout = "stdout"
+	consoleTargetStderr = "stderr"
+
+	// default period of checking whether the configuration file is modified
+	defaultScanPeriod = time.Minute
+
+	// maximum time of waiting for the queued events of replaced appenders
+	drainTimeout = 5 * time.Second
+)
+
+var (
+	levelValues = map[string]int{
+		"TRACE": TraceLevel,
+		"DEBUG": DebugLevel,
+		"INFO":  InfoLevel,
+		"WARN":  WarnLevel,
+		"ERROR": ErrorLevel,
+	}
+
+	timeGranularityValues = map[string]int{
+		"none": TimeGranularityNone,
+		"hour": TimeGranularityHour,
+		"day":  TimeGranularityDay,
+	}
+
+	durationUnits = map[string]time.Duration{
+		"millisecond":  time.Millisecond,
+		"milliseconds": time.Millisecond,
+		"second":       time.Second,
+		"seconds":      time.Second,
+		"minute":       time.Minute,
+		"minutes":      time.Minute,
+		"hour":         time.Hour,
+		"hours":        time.Hour,
+		"day":          24 * time.Hour,
+		"days":         24 * time.Hour,
+	}
+
+	fileSizeUnits = map[string]int64{
+		"":   1,
+		"B":  1,
+		"KB": 1 << 10,
+		"MB": 1 << 20,
+		"GB": 1 << 30,
+	}
+
+	// appenders created by the last configuration, which will be destroyed when configuration is applied again
+	configuredAppenders []Appender
+	configurationLock   = new(sync.Mutex)
+)
+
+type appenderDefinition struct {
+	name         string
+	appenderType string
+	config       *AppenderConfig
+}
+
+type loggerDefinition struct {
+	name          string
+	level         int
+	additivity    bool
+	appenderNames []string
+}
+
+type configuration struct {
+	appenders  []*appenderDefinition
+	root       *loggerDefinition
+	loggers    []*loggerDefinition
+	scan       bool
+	scanPeriod time.Duration
+}
+
+// configure appenders and loggers from file, format is determined by the extension of path
+// `.yaml`/`.yml` and `.json` share the same structure, `.xml` supports a subset of logback.xml
+// the whole logger tree is replaced, loggers which are not declared in the file are removed
+// if scan is enabled, the file will be checked periodically and reloaded once it is modified
+//
+//	scan: true
+//	scanPeriod: 30s
+//	appenders:
+//	  stdout:
+//	    type: console
+//	    target: stdout
+//	    layout: "%d{2006-01-02 15:04:05.999} [%p]-[%c] --- %m%n"
+//	  common:
+//	    type: file
+//	    encoder: json
+//	    queueSize: 4096
+//	    overflowPolicy: discardBelowWarn
+//	    filters:
+//	      - level: INFO
+//	    rollingPolicy:
+//	      directory: /tmp/logs
+//	      fileName: common
+//	      timeGranularity: hour
+//	      maxHistory: 10
+//	      maxFileSize: 1GB
+//	root:
+//	  level: INFO
+//	  appenders: [stdout, common]
+//	loggers:
+//	  com.acme:
+//	    level: DEBUG
+//	    additivity: false
+//	    appenders: [common]
+func ConfigureFromFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	configuration, err := parseConfigurationFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err = configuration.apply(); err != nil {
+		return err
+	}
+
+	watchConfigurationFile(path, info, configuration)
+
+	return nil
+}
+
+func parseConfigurationFile(path string) (*configuration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+		document = normalizeYamlValue(document)
+	case ".json":
+		if err = json.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	case ".xml":
+		if document, err = parseLogbackXml(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported configuration file '%s', only .yaml, .yml, .json and .xml are supported", path)
+	}
+
+	return parseConfiguration(document)
+}
+
+func parseConfiguration(document interface{}) (*configuration, error) {
+	if document == nil {
+		return nil, errors.New("configuration is empty")
+	}
+	values, err := getMap(emptyString, document, "scan", "scanPeriod", "appenders", "root", "loggers")
+	if err != nil {
+		return nil, err
+	}
+
+	configuration := &configuration{
+		appenders:  make([]*appenderDefinition, 0),
+		loggers:    make([]*loggerDefinition, 0),
+		scanPeriod: defaultScanPeriod,
+	}
+
+	if value, ok := values["scan"]; ok {
+		if configuration.scan, err = getBool("scan", value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["scanPeriod"]; ok {
+		if configuration.scanPeriod, err = getDuration("scanPeriod", value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["appenders"]; ok {
+		appenders, err := getMap("appenders", value)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedKeys(appenders) {
+			definition, err := parseAppenderDefinition(joinPath("appenders", name), name, appenders[name])
+			if err != nil {
+				return nil, err
+			}
+			configuration.appenders = append(configuration.appenders, definition)
+		}
+	}
+
+	if value, ok := values["root"]; ok {
+		configuration.root, err = parseLoggerDefinition("root", Root, value)
+		if err != nil {
+			return nil, err
+		}
+		if configuration.root.level == inheritedLevel {
+			configuration.root.level = InfoLevel
+		}
+	}
+
+	if value, ok := values["loggers"]; ok {
+		loggers, err := getMap("loggers", value)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedKeys(loggers) {
+			path := joinPath("loggers", name)
+			if isRoot(name) {
+				return nil, fmt.Errorf("%s: root logger must be configured by 'root'", path)
+			}
+			definition, err := parseLoggerDefinition(path, name, loggers[name])
+			if err != nil {
+				return nil, err
+			}
+			configuration.loggers = append(configuration.loggers, definition)
+		}
+	}
+
+	// check appender references
+	for _, definition := range configuration.allLoggerDefinitions() {
+		path := "root"
+		if !isRoot(definition.name) {
+			path = joinPath("loggers", definition.name)
+		}
+		for i, name := range definition.appenderNames {
+			if configuration.getAppenderDefinition(name) == nil {
+				return nil, fmt.Errorf("%s.appenders[%d]: unknown appender '%s'", path, i, name)
+			}
+		}
+	}
+
+	return configuration, nil
+}
+
+func parseAppenderDefinition(path string, name string, value interface{}) (*appenderDefinition, error) {
+	values, err := getMap(path, value, "type", "target", "encoder", "layout", "filters", "rollingPolicy", "syslog",
+		"socket", "queueSize", "overflowPolicy", "discardingThreshold")
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &appenderDefinition{
+		name: name,
+		config: &AppenderConfig{
+			Layout: defaultLayout,
+		},
+	}
+
+	if definition.appenderType, err = getRequiredString(path, values, "type"); err != nil {
+		return nil, err
+	}
+
+	if value, ok := values["encoder"]; ok {
+		if definition.config.Encoder, err = getString(joinPath(path, "encoder"), value); err != nil {
+			return nil, err
+		}
+		if definition.config.Encoder != EncoderPattern && definition.config.Encoder != EncoderJson {
+			return nil, fmt.Errorf("%s: unsupported encoder '%s', only %s and %s are supported",
+				joinPath(path, "encoder"), definition.config.Encoder, EncoderPattern, EncoderJson)
+		}
+	}
+
+	if value, ok := values["layout"]; ok {
+		if definition.config.Encoder == EncoderJson {
+			return nil, fmt.Errorf("%s: only pattern encoder supports layout", joinPath(path, "layout"))
+		}
+		if definition.config.Layout, err = getString(joinPath(path, "layout"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["filters"]; ok {
+		if definition.config.Filters, err = parseFilters(joinPath(path, "filters"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["queueSize"]; ok {
+		queueSize, err := getInt(joinPath(path, "queueSize"), value)
+		if err != nil {
+			return nil, err
+		}
+		if queueSize < 1 {
+			return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "queueSize"))
+		}
+		definition.config.QueueSize = int(queueSize)
+	}
+
+	if value, ok := values["overflowPolicy"]; ok {
+		if definition.config.OverflowPolicy, err = getString(joinPath(path, "overflowPolicy"), value); err != nil {
+			return nil, err
+		}
+		switch definition.config.OverflowPolicy {
+		case OverflowPolicyBlock, OverflowPolicyDropNewest, OverflowPolicyDropOldest, OverflowPolicyDiscardBelowWarn:
+		default:
+			return nil, fmt.Errorf("%s: unsupported overflow policy '%s', only %s, %s, %s and %s are supported",
+				joinPath(path, "overflowPolicy"), definition.config.OverflowPolicy,
+				OverflowPolicyBlock, OverflowPolicyDropNewest, OverflowPolicyDropOldest, OverflowPolicyDiscardBelowWarn)
+		}
+	}
+
+	if value, ok := values["discardingThreshold"]; ok {
+		discardingThreshold, err := getInt(joinPath(path, "discardingThreshold"), value)
+		if err != nil {
+			return nil, err
+		}
+		if discardingThreshold < 1 {
+			return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "discardingThreshold"))
+		}
+		if definition.config.OverflowPolicy != OverflowPolicyDiscardBelowWarn {
+			return nil, fmt.Errorf("%s: only overflow policy %s supports discardingThreshold",
+				joinPath(path, "discardingThreshold"), OverflowPolicyDiscardBelowWarn)
+		}
+		definition.config.DiscardingThreshold = int(discardingThreshold)
+	}
+
+	if _, ok := values["target"]; ok && definition.appenderType != appenderTypeConsole {
+		return nil, fmt.Errorf("%s: only console appender supports target", joinPath(path, "target"))
+	}
+	if _, ok := values["rollingPolicy"]; ok && definition.appenderType != appenderTypeFile {
+		return nil, fmt.Errorf("%s: only file appender supports rolling policy", joinPath(path, "rollingPolicy"))
+	}
+	if _, ok := values["syslog"]; ok && definition.appenderType != appenderTypeSyslog {
+		return nil, fmt.Errorf("%s: only syslog appender supports syslog", joinPath(path, "syslog"))
+	}
+	if _, ok := values["socket"]; ok && definition.appenderType != appenderTypeSocket {
+		return nil, fmt.Errorf("%s: only socket appender supports socket", joinPath(path, "socket"))
+	}
+
+	switch definition.appenderType {
+	case appenderTypeConsole:
+
+		target := consoleTargetStdout
+		if value, ok := values["target"]; ok {
+			if target, err = getString(joinPath(path, "target"), value); err != nil {
+				return nil, err
+			}
+		}
+		switch target {
+		case consoleTargetStdout:
+			definition.config.Writer = os.Stdout
+		case consoleTargetStderr:
+			definition.config.Writer = os.Stderr
+		default:
+			return nil, fmt.Errorf("%s: unsupported target '%s', only %s and %s are supported",
+				joinPath(path, "target"), target, consoleTargetStdout, consoleTargetStderr)
+		}
+	case appenderTypeFile:
+		value, ok := values["rollingPolicy"]
+		if !ok {
+			return nil, fmt.Errorf("%s: rolling policy is required for file appender", joinPath(path, "rollingPolicy"))
+		}
+		if definition.config.FileRollingPolicy, err = parseRollingPolicy(joinPath(path, "rollingPolicy"), value); err != nil {
+			return nil, err
+		}
+	case appenderTypeSyslog:
+		value, ok := values["syslog"]
+		if !ok {
+			return nil, fmt.Errorf("%s: syslog is required for syslog appender", joinPath(path, "syslog"))
+		}
+		if definition.config.Syslog, err = parseSyslogConfig(joinPath(path, "syslog"), value); err != nil {
+			return nil, err
+		}
+
+		// header is written by syslog appender, only message is encoded by layout
+		if _, ok := values["layout"]; !ok {
+			definition.config.Layout = defaultSyslogLayout
+		}
+	case appenderTypeSocket:
+		for _, key := range []string{"encoder", "layout"} {
+			if _, ok := values[key]; ok {
+				return nil, fmt.Errorf("%s: socket appender sends events without encoding", joinPath(path, key))
+			}
+		}
+
+		value, ok := values["socket"]
+		if !ok {
+			return nil, fmt.Errorf("%s: socket is required for socket appender", joinPath(path, "socket"))
+		}
+		if definition.config.Socket, err = parseSocketConfig(joinPath(path, "socket"), value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported appender type '%s', only %s, %s, %s and %s are supported",
+			joinPath(path, "type"), definition.appenderType,
+			appenderTypeConsole, appenderTypeFile, appenderTypeSyslog, appenderTypeSocket)
+	}
+
+	return definition, nil
+}
+
+func parseFilters(path string, value interface{}) ([]Filter, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a list", path)
+	}
+
+	filters := make([]Filter, 0)
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		values, err := getMap(itemPath, item, "level")
+		if err != nil {
+			return nil, err
+		}
+		levelName, err := getRequiredString(itemPath, values, "level")
+		if err != nil {
+			return nil, err
+		}
+		level, err := parseLevel(joinPath(itemPath, "level"), levelName)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, &LevelFilter{
+			LogLevelThreshold: level,
+		})
+	}
+
+	return filters, nil
+}
+
+func parseRollingPolicy(path string, value interface{}) (*RollingPolicy, error) {
+	values, err := getMap(path, value, "directory", "fileName", "timeGranularity", "fileNamePattern", "fixedWindow", "maxHistory",
+		"maxFileSize", "compress", "totalSizeCap", "maxAge", "prudent")
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &RollingPolicy{
+		TimeGranularity: TimeGranularityNone,
+	}
+
+	if policy.Directory, err = getRequiredString(path, values, "directory"); err != nil {
+		return nil, err
+	}
+
+	if policy.FileName, err = getRequiredString(path, values, "fileName"); err != nil {
+		return nil, err
+	}
+	if strings.Contains(policy.FileName, ".") {
+		return nil, fmt.Errorf("%s: file name contains '.'", joinPath(path, "fileName"))
+	}
+
+	if value, ok := values["timeGranularity"]; ok {
+		granularity, err := getString(joinPath(path, "timeGranularity"), value)
+		if err != nil {
+			return nil, err
+		}
+		if policy.TimeGranularity, ok = timeGranularityValues[strings.ToLower(granularity)]; !ok {
+			return nil, fmt.Errorf("%s: unsupported time granularity '%s', only none, hour and day are supported",
+				joinPath(path, "timeGranularity"), granularity)
+		}
+	}
+
+	if value, ok := values["fileNamePattern"]; ok {
+		if policy.FileNamePattern, err = getString(joinPath(path, "fileNamePattern"), value); err != nil {
+			return nil, err
+		}
+		if TimeGranularityNone != policy.TimeGranularity {
+			return nil, fmt.Errorf("%s: can not be used with timeGranularity", joinPath(path, "fileNamePattern"))
+		}
+		if _, err = newFileNamePattern(policy.FileNamePattern); err != nil {
+			return nil, fmt.Errorf("%s: %s", joinPath(path, "fileNamePattern"), err.Error())
+		}
+	}
+
+	if value, ok := values["fixedWindow"]; ok {
+		if policy.FixedWindow, err = getBool(joinPath(path, "fixedWindow"), value); err != nil {
+			return nil, err
+		}
+		if policy.FixedWindow && (TimeGranularityNone != policy.TimeGranularity || policy.FileNamePattern != emptyString) {
+			return nil, fmt.Errorf("%s: can not be used with timeGranularity or fileNamePattern", joinPath(path, "fixedWindow"))
+		}
+	}
+
+	value, ok := values["maxHistory"]
+	if !ok {
+		return nil, fmt.Errorf("%s: is required", joinPath(path, "maxHistory"))
+	}
+	maxHistory, err := getInt(joinPath(path, "maxHistory"), value)
+	if err != nil {
+		return nil, err
+	}
+	if maxHistory < 1 {
+		return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "maxHistory"))
+	}
+	policy.MaxHistory = int(maxHistory)
+
+	value, ok = values["maxFileSize"]
+	if !ok {
+		return nil, fmt.Errorf("%s: is required", joinPath(path, "maxFileSize"))
+	}
+	if policy.MaxFileSize, err = getFileSize(joinPath(path, "maxFileSize"), value); err != nil {
+		return nil, err
+	}
+	if policy.MaxFileSize < 1 {
+		return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "maxFileSize"))
+	}
+
+	if value, ok := values["compress"]; ok {
+		if policy.Compress, err = getBool(joinPath(path, "compress"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["totalSizeCap"]; ok {
+		if policy.TotalSizeCap, err = getFileSize(joinPath(path, "totalSizeCap"), value); err != nil {
+			return nil, err
+		}
+		if policy.TotalSizeCap < 1 {
+			return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "totalSizeCap"))
+		}
+	}
+
+	if value, ok := values["maxAge"]; ok {
+		if policy.MaxAge, err = getDuration(joinPath(path, "maxAge"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["prudent"]; ok {
+		if policy.Prudent, err = getBool(joinPath(path, "prudent"), value); err != nil {
+			return nil, err
+		}
+		if policy.Prudent && policy.Compress {
+			return nil, fmt.Errorf("%s: can not be used with compress", joinPath(path, "prudent"))
+		}
+	}
+
+	return policy, nil
+}
+
+func parseSyslogConfig(path string, value interface{}) (*SyslogConfig, error) {
+	values, err := getMap(path, value, "network", "address", "format", "facility", "appName", "hostname",
+		"structuredDataId", "reconnectInterval")
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SyslogConfig{}
+
+	if config.Network, err = getRequiredString(path, values, "network"); err != nil {
+		return nil, err
+	}
+	switch config.Network {
+	case SyslogNetworkUdp, SyslogNetworkTcp, SyslogNetworkUnix:
+	default:
+		return nil, fmt.Errorf("%s: unsupported network '%s', only %s, %s and %s are supported",
+			joinPath(path, "network"), config.Network, SyslogNetworkUdp, SyslogNetworkTcp, SyslogNetworkUnix)
+	}
+
+	if config.Address, err = getRequiredString(path, values, "address"); err != nil {
+		return nil, err
+	}
+
+	if value, ok := values["format"]; ok {
+		if config.Format, err = getString(joinPath(path, "format"), value); err != nil {
+			return nil, err
+		}
+		config.Format = strings.ToLower(config.Format)
+		if config.Format != SyslogFormatRFC5424 && config.Format != SyslogFormatRFC3164 {
+			return nil, fmt.Errorf("%s: unsupported format '%s', only %s and %s are supported",
+				joinPath(path, "format"), config.Format, SyslogFormatRFC5424, SyslogFormatRFC3164)
+		}
+	}
+
+	if value, ok := values["facility"]; ok {
+		if config.Facility, err = getString(joinPath(path, "facility"), value); err != nil {
+			return nil, err
+		}
+		config.Facility = strings.ToLower(config.Facility)
+		if _, ok := syslogFacilities[config.Facility]; !ok {
+			return nil, fmt.Errorf("%s: unsupported facility '%s'", joinPath(path, "facility"), config.Facility)
+		}
+	}
+
+	if value, ok := values["appName"]; ok {
+		if config.AppName, err = getString(joinPath(path, "appName"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["hostname"]; ok {
+		if config.Hostname, err = getString(joinPath(path, "hostname"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["structuredDataId"]; ok {
+		if config.StructuredDataId, err = getString(joinPath(path, "structuredDataId"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["reconnectInterval"]; ok {
+		if config.ReconnectInterval, err = getDuration(joinPath(path, "reconnectInterval"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+func parseSocketConfig(path string, value interface{}) (*SocketConfig, error) {
+	values, err := getMap(path, value, "address", "reconnectInterval", "backlogSize")
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SocketConfig{}
+
+	if config.Address, err = getRequiredString(path, values, "address"); err != nil {
+		return nil, err
+	}
+
+	if value, ok := values["reconnectInterval"]; ok {
+		if config.ReconnectInterval, err = getDuration(joinPath(path, "reconnectInterval"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["backlogSize"]; ok {
+		backlogSize, err := getInt(joinPath(path, "backlogSize"), value)
+		if err != nil {
+			return nil, err
+		}
+		if backlogSize < 1 {
+			return nil, fmt.Errorf("%s: must large than 0", joinPath(path, "backlogSize"))
+		}
+		config.BacklogSize = int(backlogSize)
+	}
+
+	return config, nil
+}
+
+func parseLoggerDefinition(path string, name string, value interface{}) (*loggerDefinition, error) {
+	allowedKeys := []string{"level", "additivity", "appenders"}
+	if isRoot(name) {
+		allowedKeys = []string{"level", "appenders"}
+	}
+	values, err := getMap(path, value, allowedKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &loggerDefinition{
+		name:          name,
+		level:         inheritedLevel,
+		additivity:    !isRoot(name),
+		appenderNames: make([]string, 0),
+	}
+
+	if value, ok := values["level"]; ok {
+		levelName, err := getString(joinPath(path, "level"), value)
+		if err != nil {
+			return nil, err
+		}
+		if definition.level, err = parseLevel(joinPath(path, "level"), levelName); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["additivity"]; ok {
+		if definition.additivity, err = getBool(joinPath(path, "additivity"), value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values["appenders"]; ok {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: must be a list", joinPath(path, "appenders"))
+		}
+		for i, item := range items {
+			appenderName, err := getString(fmt.Sprintf("%s[%d]", joinPath(path, "appenders"), i), item)
+			if err != nil {
+				return nil, err
+			}
+			definition.appenderNames = append(definition.appenderNames, appenderName)
+		}
+	}
+
+	return definition, nil
+}
+
+// create all the appenders and then replace the configured loggers
+func (configuration *configuration) apply() error {
+	if isShutdownCalled() {
+		return errors.New("log is already shut down")
+	}
+
+	appenders := make(map[string]Appender, 0)
+	createdAppenders := make([]Appender, 0)
+
+	for _, definition := range configuration.appenders {
+		appender, err := definition.newAppender()
+		if err != nil {
+			for _, createdAppender := range createdAppenders {
+				createdAppender.Destroy()
+			}
+			return fmt.Errorf("%s: %s", joinPath("appenders", definition.name), err.Error())
+		}
+		appenders[definition.name] = appender
+		createdAppenders = append(createdAppenders, appender)
+	}
+
+	newLoggers := make([]*loggerImpl, 0)
+	for _, definition := range configuration.allLoggerDefinitions() {
+		loggerAppenders := make([]Appender, 0)
+		for _, name := range definition.appenderNames {
+			loggerAppenders = append(loggerAppenders, appenders[name])
+		}
+		newLoggers = append(newLoggers, &loggerImpl{
+			name:         definition.name,
+			level:        int32(definition.level),
+			additivity:   definition.additivity,
+			appenders:    loggerAppenders,
+			isShadow:     false,
+			isConfigured: true,
+		})
+	}
+
+	configurationLock.Lock()
+	// shutdown may be called while creating appenders, and it only destroys the appenders which are applied before it
+	if isShutdownCalled() {
+		configurationLock.Unlock()
+		for _, createdAppender := range createdAppenders {
+			createdAppender.Destroy()
+		}
+		return errors.New("log is already shut down")
+	}
+	replaceLoggers(newLoggers)
+	previousAppenders := configuredAppenders
+	configuredAppenders = createdAppenders
+	configurationLock.Unlock()
+
+	// replaced appenders may still be used by the loggers which are bound before replacing
+	go destroyAfterDrained(previousAppenders)
+
+	return nil
+}
+
+func destroyAfterDrained(appenders []Appender) {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	for _, appender := range appenders {
+		if flushable, ok := appender.(flushableAppender); ok {
+			flushable.flush(ctx)
+		}
+		appender.Destroy()
+	}
+}
+
+func (configuration *configuration) allLoggerDefinitions() []*loggerDefinition {
+	definitions := make([]*loggerDefinition, 0)
+	if utils.IsNotNil(configuration.root) {
+		definitions = append(definitions, configuration.root)
+	}
+	return append(definitions, configuration.loggers...)
+}
+
+func (configuration *configuration) getAppenderDefinition(name string) *appenderDefinition {
+	for _, definition := range configuration.appenders {
+		if definition.name == name {
+			return definition
+		}
+	}
+	return nil
+}
+
+func (definition *appenderDefinition) newAppender() (Appender, error) {
+	switch definition.appenderType {
+	case appenderTypeConsole:
+		return NewWriterAppender(definition.config)
+	case appenderTypeFile:
+		return NewFileAppender(definition.config)
+	case appenderTypeSyslog:
+		return NewSyslogAppender(definition.config)
+	case appenderTypeSocket:
+		return NewSocketAppender(definition.config)
+	}
+	return nil, fmt.Errorf("unsupported appender type '%s'", definition.appenderType)
+}
+
+func parseLevel(path string, name string) (int, error) {
+	level, ok := levelValues[strings.ToUpper(name)]
+	if !ok {
+		return -1, fmt.Errorf("%s: unsupported log level '%s'", path, name)
+	}
+	return level, nil
+}
+
+// yaml decodes mappings as map[interface{}]interface{}, convert them to map[string]interface{} like json
+func normalizeYamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			values[fmt.Sprintf("%v", key)] = normalizeYamlValue(item)
+		}
+		return values
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeYamlValue(item)
+		}
+		return items
+	}
+	return value
+}
+
+func joinPath(path string, key string) string {
+	if path == emptyString {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// get map value, and check that it contains only the allowed keys
+func getMap(path string, value interface{}, allowedKeys ...string) (map[string]interface{}, error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		if path == emptyString {
+			return nil, errors.New("configuration must be a mapping")
+		}
+		return nil, fmt.Errorf("%s: must be a mapping", path)
+	}
+
+	if len(allowedKeys) > 0 {
+		for _, key := range sortedKeys(values) {
+			allowed := false
+			for _, allowedKey := range allowedKeys {
+				if key == allowedKey {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, fmt.Errorf("%s: unknown key", joinPath(path, key))
+			}
+		}
+	}
+
+	return values, nil
+}
+
+func getRequiredString(path string, values map[string]interface{}, key string) (string, error) {
+	value, ok := values[key]
+	if !ok {
+		return emptyString, fmt.Errorf("%s: is required", joinPath(path, key))
+	}
+	return getString(joinPath(path, key), value)
+}
+
+func getString(path string, value interface{}) (string, error) {
+	v, ok := value.(string)
+	if !ok || v == emptyString {
+		return emptyString, fmt.Errorf("%s: must be a non-empty string", path)
+	}
+	return v, nil
+}
+
+func getBool(path string, value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("%s: must be a boolean", path)
+}
+
+func getInt(path string, value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: must be an integer", path)
+}
+
+// duration can be go's duration like `30s`, or logback's duration like `30 seconds`
+func getDuration(path string, value interface{}) (time.Duration, error) {
+	text, err := getString(path, value)
+	if err != nil {
+		return 0, err
+	}
+
+	period, err := time.ParseDuration(text)
+	if err != nil {
+		segments := strings.Fields(text)
+		if len(segments) != 2 {
+			return 0, fmt.Errorf("%s: invalid duration '%s'", path, text)
+		}
+		count, countErr := strconv.ParseInt(segments[0], 10, 64)
+		unit, ok := durationUnits[strings.ToLower(segments[1])]
+		if countErr != nil || !ok {
+			return 0, fmt.Errorf("%s: invalid duration '%s'", path, text)
+		}
+		period = time.Duration(count) * unit
+	}
+
+	if period <= 0 {
+		return 0, fmt.Errorf("%s: must large than 0", path)
+	}
+
+	return period, nil
+}
+
+// file size can be an integer of bytes, or a string with unit, like `512KB`, `10MB`, `1GB`
+func getFileSize(path string, value interface{}) (int64, error) {
+	text, ok := value.(string)
+	if !ok {
+		return getInt(path, value)
+	}
+
+	text = strings.ToUpper(strings.TrimSpace(text))
+	index := strings.IndexFunc(text, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if index == 0 {
+		return 0, fmt.Errorf("%s: invalid file size '%s'", path, value)
+	}
+	if index < 0 {
+		index = len(text)
+	}
+
+	size, err := strconv.ParseInt(text[:index], 10, 64)
+	unit, ok := fileSizeUnits[strings.TrimSpace(text[index:])]
+	if err != nil || !ok {
+		return 0, fmt.Errorf("%s: invalid file size '%s'", path, value)
+	}
+
+	return size * unit, nil
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"os"
+	"time"
+)
+
+var (
+	// watcher of the last configuration file, guarded by configurationLock
+	configurationWatcher *fileWatcher
+)
+
+// check modification of the configuration file periodically, and reload it once modified
+type fileWatcher struct {
+	path    string
+	period  time.Duration
+	modTime time.Time
+	size    int64
+	stop    chan struct{}
+}
+
+// stop watching the previous configuration file, and start watching the specified one if scan is enabled
+func watchConfigurationFile(path string, info os.FileInfo, configuration *configuration) {
+	configurationLock.Lock()
+	defer configurationLock.Unlock()
+
+	stopConfigurationWatcher()
+
+	// watcher stopped by shutdown must not be restarted by the reloading which is running concurrently
+	if !configuration.scan || isShutdownCalled() {
+		return
+	}
+
+	configurationWatcher = &fileWatcher{
+		path:    path,
+		period:  configuration.scanPeriod,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		stop:    make(chan struct{}),
+	}
+
+	go configurationWatcher.onCheckLoop()
+}
+
+// caller must hold configurationLock
+func stopConfigurationWatcher() {
+	if configurationWatcher != nil {
+		close(configurationWatcher.stop)
+		configurationWatcher = nil
+	}
+}
+
+func (watcher *fileWatcher) onCheckLoop() {
+	ticker := time.NewTicker(watcher.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-watcher.stop:
+			return
+		case <-ticker.C:
+			if watcher.reloadIfModified() {
+				return
+			}
+		}
+	}
+}
+
+// return true if this watcher is replaced by the reloaded configuration, or stopped by shutdown
+func (watcher *fileWatcher) reloadIfModified() bool {
+	info, err := os.Stat(watcher.path)
+	if err != nil {
+		return false
+	}
+	if info.ModTime().Equal(watcher.modTime) && info.Size() == watcher.size {
+		return false
+	}
+	watcher.modTime = info.ModTime()
+	watcher.size = info.Size()
+
+	configuration, err := parseConfigurationFile(watcher.path)
+	if err == nil {
+		err = configuration.apply()
+	}
+	if isShutdownCalled() {
+		return true
+	}
+	if err != nil {
+		// keep the current configuration, and wait for the next modification
+		getRootLogger().Error("failed to reload configuration file '{}', {}", watcher.path, err)
+		return false
+	}
+
+	if configuration.scan && configuration.scanPeriod == watcher.period {
+		return false
+	}
+
+	watchConfigurationFile(watcher.path, info, configuration)
+	return true
+}
@@ -0,0 +1,395 @@
+package log
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// subset of logback.xml
+type xmlConfiguration struct {
+	XMLName    xml.Name      `xml:"configuration"`
+	Scan       string        `xml:"scan,attr"`
+	ScanPeriod string        `xml:"scanPeriod,attr"`
+	Appenders  []xmlAppender `xml:"appender"`
+	Loggers    []xmlLogger   `xml:"logger"`
+	Root       *xmlLogger    `xml:"root"`
+	Unknown    []xmlUnknown  `xml:",any"`
+}
+
+type xmlAppender struct {
+	Name             string            `xml:"name,attr"`
+	Class            string            `xml:"class,attr"`
+	Target           string            `xml:"target"`
+	File             string            `xml:"file"`
+	Prudent          string            `xml:"prudent"`
+	Encoder          *xmlEncoder       `xml:"encoder"`
+	Layout           *xmlEncoder       `xml:"layout"`
+	Filters          []xmlFilter       `xml:"filter"`
+	RollingPolicy    *xmlRollingPolicy `xml:"rollingPolicy"`
+	TriggeringPolicy *xmlRollingPolicy `xml:"triggeringPolicy"`
+	Unknown          []xmlUnknown      `xml:",any"`
+}
+
+type xmlEncoder struct {
+	Class   string       `xml:"class,attr"`
+	Pattern string       `xml:"pattern"`
+	Unknown []xmlUnknown `xml:",any"`
+}
+
+type xmlFilter struct {
+	Class   string       `xml:"class,attr"`
+	Level   string       `xml:"level"`
+	Unknown []xmlUnknown `xml:",any"`
+}
+
+type xmlRollingPolicy struct {
+	Class           string       `xml:"class,attr"`
+	FileNamePattern string       `xml:"fileNamePattern"`
+	MaxHistory      string       `xml:"maxHistory"`
+	MaxFileSize     string       `xml:"maxFileSize"`
+	TotalSizeCap    string       `xml:"totalSizeCap"`
+	MinIndex        string       `xml:"minIndex"`
+	MaxIndex        string       `xml:"maxIndex"`
+	Unknown         []xmlUnknown `xml:",any"`
+}
+
+type xmlLogger struct {
+	Name         string           `xml:"name,attr"`
+	Level        string           `xml:"level,attr"`
+	Additivity   string           `xml:"additivity,attr"`
+	AppenderRefs []xmlAppenderRef `xml:"appender-ref"`
+	Unknown      []xmlUnknown     `xml:",any"`
+}
+
+type xmlAppenderRef struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type xmlUnknown struct {
+	XMLName xml.Name
+}
+
+// convert logback.xml to the same document structure as yaml and json
+func parseLogbackXml(data []byte) (interface{}, error) {
+	var config xmlConfiguration
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Unknown) > 0 {
+		return nil, fmt.Errorf("configuration.%s: unsupported element", config.Unknown[0].XMLName.Local)
+	}
+
+	document := make(map[string]interface{}, 0)
+
+	if config.Scan != emptyString {
+		document["scan"] = config.Scan
+	}
+	if config.ScanPeriod != emptyString {
+		document["scanPeriod"] = config.ScanPeriod
+	}
+
+	appenders := make(map[string]interface{}, 0)
+	for _, appender := range config.Appenders {
+		path := fmt.Sprintf("appender[%s]", appender.Name)
+		if appender.Name == emptyString {
+			return nil, fmt.Errorf("%s: name is required", path)
+		}
+		if _, ok := appenders[appender.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate appender", path)
+		}
+		if len(appender.Unknown) > 0 {
+			return nil, fmt.Errorf("%s.%s: unsupported element", path, appender.Unknown[0].XMLName.Local)
+		}
+
+		values, err := convertXmlAppender(path, &appender)
+		if err != nil {
+			return nil, err
+		}
+		appenders[appender.Name] = values
+	}
+	document["appenders"] = appenders
+
+	if config.Root != nil {
+		values, err := convertXmlLogger("root", config.Root)
+		if err != nil {
+			return nil, err
+		}
+		document["root"] = values
+	}
+
+	loggers := make(map[string]interface{}, 0)
+	for _, logger := range config.Loggers {
+		path := fmt.Sprintf("logger[%s]", logger.Name)
+		if logger.Name == emptyString {
+			return nil, fmt.Errorf("logger: name is required")
+		}
+		if _, ok := loggers[logger.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate logger", path)
+		}
+
+		values, err := convertXmlLogger(path, &logger)
+		if err != nil {
+			return nil, err
+		}
+		loggers[logger.Name] = values
+	}
+	document["loggers"] = loggers
+
+	return document, nil
+}
+
+func convertXmlAppender(path string, appender *xmlAppender) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, 0)
+
+	className := appender.Class[strings.LastIndex(appender.Class, ".")+1:]
+	switch className {
+	case "ConsoleAppender":
+		values["type"] = appenderTypeConsole
+		switch appender.Target {
+		case emptyString:
+		case "System.out":
+			values["target"] = consoleTargetStdout
+		case "System.err":
+			values["target"] = consoleTargetStderr
+		default:
+			values["target"] = appender.Target
+		}
+	case "FileAppender", "RollingFileAppender":
+		values["type"] = appenderTypeFile
+		policy, err := convertXmlRollingPolicy(path, appender)
+		if err != nil {
+			return nil, err
+		}
+		values["rollingPolicy"] = policy
+	default:
+		values["type"] = appender.Class
+	}
+
+	if appender.Encoder != nil && len(appender.Encoder.Unknown) > 0 {
+		return nil, fmt.Errorf("%s.encoder.%s: unsupported element", path, appender.Encoder.Unknown[0].XMLName.Local)
+	}
+	if appender.Layout != nil && len(appender.Layout.Unknown) > 0 {
+		return nil, fmt.Errorf("%s.layout.%s: unsupported element", path, appender.Layout.Unknown[0].XMLName.Local)
+	}
+
+	// logback's JsonEncoder and logstash's LogstashEncoder
+	if appender.Encoder != nil && (strings.HasSuffix(appender.Encoder.Class, "JsonEncoder") ||
+		strings.HasSuffix(appender.Encoder.Class, "LogstashEncoder")) {
+		values["encoder"] = EncoderJson
+	} else if appender.Encoder != nil && appender.Encoder.Pattern != emptyString {
+		values["layout"] = strings.TrimSpace(appender.Encoder.Pattern)
+	} else if appender.Layout != nil && appender.Layout.Pattern != emptyString {
+		values["layout"] = strings.TrimSpace(appender.Layout.Pattern)
+	}
+
+	if len(appender.Filters) > 0 {
+		filters := make([]interface{}, 0)
+		for i, filter := range appender.Filters {
+			if !strings.HasSuffix(filter.Class, "ThresholdFilter") {
+				return nil, fmt.Errorf("%s.filter[%d]: unsupported filter class '%s', only ThresholdFilter is supported", path, i, filter.Class)
+			}
+			if len(filter.Unknown) > 0 {
+				return nil, fmt.Errorf("%s.filter[%d].%s: unsupported element", path, i, filter.Unknown[0].XMLName.Local)
+			}
+			filters = append(filters, map[string]interface{}{
+				"level": filter.Level,
+			})
+		}
+		values["filters"] = filters
+	}
+
+	return values, nil
+}
+
+func convertXmlRollingPolicy(path string, appender *xmlAppender) (map[string]interface{}, error) {
+	if appender.File == emptyString {
+		return nil, fmt.Errorf("%s.file: is required", path)
+	}
+	if !strings.HasSuffix(appender.File, fileSuffix) {
+		return nil, fmt.Errorf("%s.file: must end with '%s'", path, fileSuffix)
+	}
+
+	policy := map[string]interface{}{
+		"directory": filepath.Dir(appender.File),
+		"fileName":  strings.TrimSuffix(filepath.Base(appender.File), fileSuffix),
+	}
+
+	if appender.Prudent != emptyString {
+		policy["prudent"] = strings.TrimSpace(appender.Prudent)
+	}
+
+	fileNamePattern := emptyString
+	for _, rollingPolicy := range []*xmlRollingPolicy{appender.RollingPolicy, appender.TriggeringPolicy} {
+		if rollingPolicy == nil {
+			continue
+		}
+		if len(rollingPolicy.Unknown) > 0 {
+			return nil, fmt.Errorf("%s.rollingPolicy.%s: unsupported element", path, rollingPolicy.Unknown[0].XMLName.Local)
+		}
+		if rollingPolicy.FileNamePattern != emptyString {
+			fileNamePattern = strings.TrimSpace(rollingPolicy.FileNamePattern)
+		}
+		if strings.HasSuffix(rollingPolicy.Class, "FixedWindowRollingPolicy") {
+			// files are named from 1 to maxIndex, which is 7 by default in logback
+			policy["fixedWindow"] = true
+			policy["maxHistory"] = "7"
+			if minIndex := strings.TrimSpace(rollingPolicy.MinIndex); minIndex != emptyString && minIndex != "1" {
+				return nil, fmt.Errorf("%s.rollingPolicy.minIndex: only 1 is supported", path)
+			}
+			if rollingPolicy.MaxIndex != emptyString {
+				policy["maxHistory"] = strings.TrimSpace(rollingPolicy.MaxIndex)
+			}
+		}
+		if rollingPolicy.MaxHistory != emptyString {
+			policy["maxHistory"] = strings.TrimSpace(rollingPolicy.MaxHistory)
+		}
+		if rollingPolicy.MaxFileSize != emptyString {
+			policy["maxFileSize"] = strings.TrimSpace(rollingPolicy.MaxFileSize)
+		}
+		if rollingPolicy.TotalSizeCap != emptyString {
+			policy["totalSizeCap"] = strings.TrimSpace(rollingPolicy.TotalSizeCap)
+		}
+	}
+
+	if fileNamePattern != emptyString {
+		if err := convertXmlFileNamePattern(path, appender.File, fileNamePattern, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// logback's pattern like `/var/log/app.%d{yyyy-MM-dd_HH}.%i.log.gz` is translated to the FileNamePattern in directory
+// of file, like `app.%d{2006-01-02_15}.%i.log`, patterns which can not be represented are rejected
+func convertXmlFileNamePattern(path string, file string, fileNamePattern string, policy map[string]interface{}) error {
+	patternPath := fmt.Sprintf("%s.rollingPolicy.fileNamePattern", path)
+
+	if filepath.Dir(fileNamePattern) != filepath.Dir(file) {
+		return fmt.Errorf("%s: must be in the directory of file", patternPath)
+	}
+	name := filepath.Base(fileNamePattern)
+
+	// like logback, rolled files are compressed if the pattern ends with `.gz`
+	if strings.HasSuffix(name, gzipSuffix) {
+		policy["compress"] = true
+		name = strings.TrimSuffix(name, gzipSuffix)
+	}
+
+	// names of fixed window are not customizable
+	if policy["fixedWindow"] == true {
+		expected := policy["fileName"].(string) + ".%i" + fileSuffix
+		if name != expected {
+			return fmt.Errorf("%s: only '%s' is supported for fixed window", patternPath, expected)
+		}
+		return nil
+	}
+
+	translated := new(strings.Builder)
+	for ; len(name) > 0; {
+		if !strings.HasPrefix(name, "%d") {
+			translated.WriteByte(name[0])
+			name = name[1:]
+			continue
+		}
+
+		name = name[2:]
+		translated.WriteString("%d")
+		if !strings.HasPrefix(name, "{") {
+			continue
+		}
+
+		end := strings.Index(name, "}")
+		if end < 0 {
+			return fmt.Errorf("%s: contains unclosed '{'", patternPath)
+		}
+		layout, err := convertJavaDatePattern(name[1:end])
+		if err != nil {
+			return fmt.Errorf("%s: %s", patternPath, err.Error())
+		}
+		translated.WriteString("{" + layout + "}")
+		name = name[end+1:]
+	}
+
+	policy["fileNamePattern"] = translated.String()
+	return nil
+}
+
+// letters of java's SimpleDateFormat, which are translated to go layout
+var javaDateTokens = map[string]string{
+	"yyyy": "2006",
+	"yy":   "06",
+	"MMMM": "January",
+	"MMM":  "Jan",
+	"MM":   "01",
+	"M":    "1",
+	"dd":   "02",
+	"d":    "2",
+	"HH":   "15",
+	"hh":   "03",
+	"h":    "3",
+	"mm":   "04",
+	"m":    "4",
+	"ss":   "05",
+	"s":    "5",
+	"EEEE": "Monday",
+	"EEE":  "Mon",
+	"a":    "PM",
+}
+
+// `yyyy-MM-dd_HH-mm` -> `2006-01-02_15-04`, quoted text, time zone and week of year are not supported
+func convertJavaDatePattern(pattern string) (string, error) {
+	if pattern == emptyString {
+		return emptyString, nil
+	}
+
+	layout := new(strings.Builder)
+	for ; len(pattern) > 0; {
+		c := pattern[0]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			if (c >= '0' && c <= '9') || c == '\'' || c == ',' {
+				return emptyString, fmt.Errorf("unsupported character '%c' in date pattern", c)
+			}
+			layout.WriteByte(c)
+			pattern = pattern[1:]
+			continue
+		}
+
+		end := 1
+		for ; end < len(pattern) && pattern[end] == c; {
+			end += 1
+		}
+		token, ok := javaDateTokens[pattern[:end]]
+		if !ok {
+			return emptyString, fmt.Errorf("unsupported date token '%s'", pattern[:end])
+		}
+		layout.WriteString(token)
+		pattern = pattern[end:]
+	}
+	return layout.String(), nil
+}
+
+func convertXmlLogger(path string, logger *xmlLogger) (map[string]interface{}, error) {
+	if len(logger.Unknown) > 0 {
+		return nil, fmt.Errorf("%s.%s: unsupported element", path, logger.Unknown[0].XMLName.Local)
+	}
+
+	values := make(map[string]interface{}, 0)
+
+	if logger.Level != emptyString {
+		values["level"] = logger.Level
+	}
+	if logger.Additivity != emptyString {
+		values["additivity"] = logger.Additivity
+	}
+
+	appenderNames := make([]interface{}, 0)
+	for _, ref := range logger.AppenderRefs {
+		appenderNames = append(appenderNames, ref.Ref)
+	}
+	values["appenders"] = appenderNames
+
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"net"
+	"time"
+)
+
+const (
+	dialTimeout  = 3 * time.Second
+	writeTimeout = 3 * time.Second
+)
+
+// connection of syslog and socket appenders, which is dialed lazily and dialed again after failed writing,
+// dialing is not attempted again within reconnect interval, so that a dead server does not block the event loop
+type reconnectingConnection struct {
+	dial              func() (net.Conn, error)
+	reconnectInterval time.Duration
+
+	// only accessed by event loop
+	conn         net.Conn
+	lastDialTime time.Time
+}
+
+func newReconnectingConnection(dial func() (net.Conn, error), reconnectInterval time.Duration) *reconnectingConnection {
+	return &reconnectingConnection{
+		dial:              dial,
+		reconnectInterval: reconnectInterval,
+	}
+}
+
+// true if the connection is established, either before or by this call
+func (connection *reconnectingConnection) connect() bool {
+	if connection.conn != nil {
+		return true
+	}
+	if time.Since(connection.lastDialTime) < connection.reconnectInterval {
+		return false
+	}
+	connection.lastDialTime = time.Now()
+
+	conn, err := connection.dial()
+	if err != nil {
+		return false
+	}
+
+	connection.conn = conn
+	return true
+}
+
+// the connection is closed if failed, and dialed again by the next connect
+func (connection *reconnectingConnection) write(content []byte) bool {
+	if !connection.connect() {
+		return false
+	}
+
+	_ = connection.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := connection.conn.Write(content); err != nil {
+		connection.close()
+		return false
+	}
+	return true
+}
+
+func (connection *reconnectingConnection) close() {
+	if connection.conn != nil {
+		_ = connection.conn.Close()
+		connection.conn = nil
+	}
+}
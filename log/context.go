@@ -0,0 +1,84 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// key of trace id in the values extracted from context, printed by `%traceId`
+	TraceIdKey = "traceId"
+
+	// key of span id in the values extracted from context, printed by `%spanId`
+	SpanIdKey = "spanId"
+)
+
+type traceIdContextKey struct{}
+
+type spanIdContextKey struct{}
+
+// extract values from context, such as trace id and span id installed by tracing middleware
+// nil or empty slice should be returned if there is nothing to extract
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	contextExtractors    = []ContextExtractor{extractTraceContext}
+	contextExtractorLock = new(sync.RWMutex)
+)
+
+// register an extractor, the extracted values are attached to logging events of loggers returned by Ctx
+func RegisterContextExtractor(extractor ContextExtractor) {
+	contextExtractorLock.Lock()
+	defer contextExtractorLock.Unlock()
+
+	contextExtractors = append(contextExtractors, extractor)
+}
+
+// return a copy of ctx carrying the trace id, which can be extracted by the default extractor
+func ContextWithTraceId(ctx context.Context, traceId string) context.Context {
+	return context.WithValue(ctx, traceIdContextKey{}, traceId)
+}
+
+// return a copy of ctx carrying the span id, which can be extracted by the default extractor
+func ContextWithSpanId(ctx context.Context, spanId string) context.Context {
+	return context.WithValue(ctx, spanIdContextKey{}, spanId)
+}
+
+func extractContext(ctx context.Context) []Field {
+	contextExtractorLock.RLock()
+	defer contextExtractorLock.RUnlock()
+
+	var fields []Field
+	for _, extractor := range contextExtractors {
+		fields = mergeFields(fields, extractor(ctx))
+	}
+	return fields
+}
+
+// default extractor of the values installed by ContextWithTraceId and ContextWithSpanId
+func extractTraceContext(ctx context.Context) []Field {
+	var fields []Field
+
+	if traceId, ok := ctx.Value(traceIdContextKey{}).(string); ok {
+		fields = append(fields, NewField(TraceIdKey, traceId))
+	}
+	if spanId, ok := ctx.Value(spanIdContextKey{}).(string); ok {
+		fields = append(fields, NewField(SpanIdKey, spanId))
+	}
+
+	return fields
+}
+
+// get the value extracted from context, the last one wins if there are duplicate keys
+func (event *LoggingEvent) GetContextValue(key string) (interface{}, bool) {
+	return getContextValue(event, key)
+}
+
+func getContextValue(event *LoggingEvent, key string) (interface{}, bool) {
+	for i := len(event.Context) - 1; i >= 0; i -= 1 {
+		if event.Context[i].Key == key {
+			return event.Context[i].Value, true
+		}
+	}
+	return nil, false
+}
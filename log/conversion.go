@@ -0,0 +1,87 @@
+package log
+
+import (
+	"errors"
+	"sync"
+)
+
+// custom conversion of pattern layout, like `%tenant`
+// alignment and truncation of format modifiers are applied to the converted string
+type Conversion interface {
+	Convert(event *LoggingEvent) string
+}
+
+// adapter to use ordinary function as Conversion
+type ConversionFunc func(event *LoggingEvent) string
+
+func (conversion ConversionFunc) Convert(event *LoggingEvent) string {
+	return conversion(event)
+}
+
+// create conversion with the options of layout, like `["a", "b"]` of `%tenant{a}{b}`
+// options is empty if there is no option block, and the returned error is reported along with the position in layout
+type ConversionFactory func(options []string) (Conversion, error)
+
+var (
+	converterFactoryLock = new(sync.RWMutex)
+)
+
+// register custom conversion, which can be used by the layouts of appenders created afterwards
+//
+//	log.RegisterConversion([]string{"tenant"}, func(options []string) (log.Conversion, error) {
+//		return log.ConversionFunc(func(event *log.LoggingEvent) string {
+//			return event.Mdc["tenant"]
+//		}), nil
+//	})
+//
+// words must consist of letters, digits or underscores, and must not be registered already, including the builtin ones
+func RegisterConversion(words []string, factory ConversionFactory) error {
+	if len(words) == 0 {
+		return errors.New("words of conversion is required")
+	}
+	if factory == nil {
+		return errors.New("factory of conversion is required")
+	}
+
+	converterFactoryLock.Lock()
+	defer converterFactoryLock.Unlock()
+
+	for _, word := range words {
+		if word == emptyString {
+			return errors.New("word of conversion must not be empty")
+		}
+		for _, c := range word {
+			if !isKeywordRune(c) {
+				return errors.New("word of conversion '" + word + "' contains invalid character")
+			}
+		}
+		if _, ok := converterFactories[word]; ok || isCompositeWord(word) {
+			return errors.New("word of conversion '" + word + "' is already registered")
+		}
+	}
+
+	registerConverterFactory(words, func(options []string) (converter, error) {
+		conversion, err := factory(options)
+		if err != nil {
+			return nil, err
+		}
+		if conversion == nil {
+			return nil, errors.New("factory of conversion returns nil")
+		}
+		return &customConverter{
+			conversion: conversion,
+		}, nil
+	})
+
+	return nil
+}
+
+// custom converter, adapts Conversion to converter
+type customConverter struct {
+	abstractConverter
+	conversion Conversion
+}
+
+func (converter *customConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(converter.conversion.Convert(event)))
+}
@@ -1,16 +1,29 @@
 package log
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	leftAlign  = 0
 	rightAlign = 1
 
-	unlimitedWidth = -1
-	blank          = ' '
+	unlimitedWidth  = -1
+	unlimitedLength = -1
+	blank           = ' '
+)
+
+var (
+	// no alignment and truncation
+	defaultModifier = formatModifier{
+		alignType: rightAlign,
+		width:     unlimitedWidth,
+		maxWidth:  unlimitedWidth,
+	}
 )
 
 type converter interface {
@@ -22,12 +35,24 @@ type converter interface {
 
 	// get next converter of converter chain
 	getNext() converter
+
+	// set alignment and truncation
+	setModifier(modifier formatModifier)
 }
 
-type abstractConverter struct {
-	next      converter
+// format modifier like `-20.30`
+type formatModifier struct {
 	alignType int
 	width     int
+
+	// content longer than maxWidth is truncated from the beginning, or from the end if isTruncateFromEnd is true
+	maxWidth          int
+	isTruncateFromEnd bool
+}
+
+type abstractConverter struct {
+	formatModifier
+	next converter
 }
 
 func (converter *abstractConverter) setNext(next converter) {
@@ -38,12 +63,25 @@ func (converter *abstractConverter) getNext() converter {
 	return converter.next
 }
 
+func (converter *abstractConverter) setModifier(modifier formatModifier) {
+	converter.formatModifier = modifier
+}
+
 func (converter *abstractConverter) truncAlign(content string) string {
-	if converter.width == unlimitedWidth {
+	if converter.width == unlimitedWidth && converter.maxWidth == unlimitedWidth {
 		return content
 	}
 
 	runes := []rune(content)
+	if converter.maxWidth != unlimitedWidth && len(runes) > converter.maxWidth {
+		if converter.isTruncateFromEnd {
+			runes = runes[:converter.maxWidth]
+		} else {
+			runes = runes[len(runes)-converter.maxWidth:]
+		}
+		content = string(runes)
+	}
+
 	if len(runes) >= converter.width {
 		return content
 	} else {
@@ -94,10 +132,65 @@ func (converter *literalConverter) convert(event *LoggingEvent) []byte {
 // logger converter
 type loggerConverter struct {
 	abstractConverter
+
+	// target length of abbreviated logger name, like `%c{20}`
+	length int
 }
 
 func (converter *loggerConverter) convert(event *LoggingEvent) []byte {
-	return []byte(converter.truncAlign(event.Name))
+	return []byte(converter.truncAlign(abbreviateName(event.Name, converter.length)))
+}
+
+// abbreviate dot or slash separated name like logback, leading segments are shortened to initials from left to right
+// until the name fits within length, and the last segment is always kept whole
+// only the last segment is kept if length is 0, and name is left as is if length is unlimited
+//
+//	github.com/liuyehcf/common-gtools/log with length 25 -> g.c/l/common-gtools/log
+func abbreviateName(name string, length int) string {
+	if length == unlimitedLength {
+		return name
+	}
+
+	runes := []rune(name)
+	lastSeparator := -1
+	for i, c := range runes {
+		if isNameSeparator(c) {
+			lastSeparator = i
+		}
+	}
+
+	if length == 0 {
+		return string(runes[lastSeparator+1:])
+	}
+	if len(runes) <= length || lastSeparator < 0 {
+		return name
+	}
+
+	buffer := bytes.Buffer{}
+	remaining := len(runes)
+	segmentStart := 0
+	for i := 0; i <= lastSeparator; i += 1 {
+		if !isNameSeparator(runes[i]) {
+			continue
+		}
+
+		segment := runes[segmentStart:i]
+		if remaining > length && len(segment) > 1 {
+			remaining -= len(segment) - 1
+			segment = segment[:1]
+		}
+		buffer.WriteString(string(segment))
+		buffer.WriteRune(runes[i])
+
+		segmentStart = i + 1
+	}
+	buffer.WriteString(string(runes[lastSeparator+1:]))
+
+	return buffer.String()
+}
+
+func isNameSeparator(c rune) bool {
+	return c == '.' || c == '/'
 }
 
 // date converter
@@ -121,6 +214,71 @@ func (converter *lineConverter) convert(event *LoggingEvent) []byte {
 	return []byte(converter.truncAlign(fmt.Sprintf("%s:%d", simpleFileName, event.Line)))
 }
 
+// method converter, prints function name without package, like `(*server).handle`
+type methodConverter struct {
+	abstractConverter
+}
+
+func (converter *methodConverter) convert(event *LoggingEvent) []byte {
+	_, method := splitFunctionName(event.Function)
+	return []byte(converter.truncAlign(method))
+}
+
+// class converter, prints package import path, like `github.com/liuyehcf/common-gtools/log`
+type classConverter struct {
+	abstractConverter
+}
+
+func (converter *classConverter) convert(event *LoggingEvent) []byte {
+	pkg, _ := splitFunctionName(event.Function)
+	return []byte(converter.truncAlign(pkg))
+}
+
+// file converter, prints full path of source file
+type fileConverter struct {
+	abstractConverter
+}
+
+func (converter *fileConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(event.File))
+}
+
+// thread converter, prints goroutine id
+type threadConverter struct {
+	abstractConverter
+}
+
+func (converter *threadConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(strconv.FormatInt(event.GoroutineId, 10)))
+}
+
+// pid converter
+type pidConverter struct {
+	abstractConverter
+}
+
+func (converter *pidConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(strconv.Itoa(processId)))
+}
+
+// hostname converter
+type hostnameConverter struct {
+	abstractConverter
+}
+
+func (converter *hostnameConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(hostName))
+}
+
+// relative converter, prints milliseconds elapsed since the start of process
+type relativeConverter struct {
+	abstractConverter
+}
+
+func (converter *relativeConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(strconv.FormatInt(int64(event.Timestamp.Sub(startTime)/time.Millisecond), 10)))
+}
+
 // message converter
 type messageConverter struct {
 	abstractConverter
@@ -130,6 +288,104 @@ func (converter *messageConverter) convert(event *LoggingEvent) []byte {
 	return []byte(converter.truncAlign(event.GetFormattedMessage()))
 }
 
+// mdc converter
+type mdcConverter struct {
+	abstractConverter
+	key string
+}
+
+func (converter *mdcConverter) convert(event *LoggingEvent) []byte {
+	// print all the values if key is not specified
+	if converter.key == emptyString {
+		return []byte(converter.truncAlign(formatMdc(event.Mdc)))
+	}
+	return []byte(converter.truncAlign(event.Mdc[converter.key]))
+}
+
+// fields converter
+type fieldsConverter struct {
+	abstractConverter
+}
+
+func (converter *fieldsConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.truncAlign(formatFields(event.Fields)))
+}
+
+// context converter, prints the value extracted from context
+type contextConverter struct {
+	abstractConverter
+	key string
+}
+
+func (converter *contextConverter) convert(event *LoggingEvent) []byte {
+	value, ok := getContextValue(event, converter.key)
+	if !ok {
+		return []byte(converter.truncAlign(emptyString))
+	}
+	return []byte(converter.truncAlign(stringify(value)))
+}
+
+// throwable converter, prints the error and its causes, nothing is printed if there is no throwable
+type throwableConverter struct {
+	abstractConverter
+}
+
+func (converter *throwableConverter) convert(event *LoggingEvent) []byte {
+	if event.Throwable == nil {
+		return nil
+	}
+	return []byte(formatThrowable(event.Throwable))
+}
+
+// no throwable converter, prints nothing but prevents the implicit throwable converter
+type noThrowableConverter struct {
+	abstractConverter
+}
+
+func (converter *noThrowableConverter) convert(event *LoggingEvent) []byte {
+	return nil
+}
+
+// composite converter, converts the nested converter chain as a whole
+// the width applies to the output of the nested converters, before it is wrapped with escape sequence
+type compositeConverter struct {
+	abstractConverter
+	child          converter
+	isColorEnabled bool
+}
+
+func (converter *compositeConverter) convertChild(event *LoggingEvent) string {
+	return converter.truncAlign(string(convertChain(converter.child, event)))
+}
+
+// group converter, like `%-30(%d [%t])`
+type groupConverter struct {
+	compositeConverter
+}
+
+func (converter *groupConverter) convert(event *LoggingEvent) []byte {
+	return []byte(converter.convertChild(event))
+}
+
+// color converter, like `%red(...)`
+type colorConverter struct {
+	compositeConverter
+	code string
+}
+
+func (converter *colorConverter) convert(event *LoggingEvent) []byte {
+	return []byte(colorize(converter.convertChild(event), converter.code, converter.isColorEnabled))
+}
+
+// highlight converter, like `%highlight(...)`, the colour depends on the level
+type highlightConverter struct {
+	compositeConverter
+}
+
+func (converter *highlightConverter) convert(event *LoggingEvent) []byte {
+	return []byte(colorize(converter.convertChild(event), getHighlightCode(event.Level), converter.isColorEnabled))
+}
+
 // newline converter
 type newlineConverter struct {
 	abstractConverter
@@ -145,18 +401,5 @@ type levelConverter struct {
 }
 
 func (converter *levelConverter) convert(event *LoggingEvent) []byte {
-	switch event.Level {
-	case TraceLevel:
-		return []byte(converter.truncAlign("TRACE"))
-	case DebugLevel:
-		return []byte(converter.truncAlign("DEBUG"))
-	case InfoLevel:
-		return []byte(converter.truncAlign("INFO"))
-	case WarnLevel:
-		return []byte(converter.truncAlign("WARN"))
-	case ErrorLevel:
-		return []byte(converter.truncAlign("ERROR"))
-	}
-
-	panic(fmt.Sprintf("unsupported log level '%d'", event.Level))
+	return []byte(converter.truncAlign(getLevelName(event.Level)))
 }
@@ -1,6 +1,30 @@
 package log
 
+import (
+	"errors"
+)
+
+const (
+	// encode logging event by layout
+	EncoderPattern = "pattern"
+
+	// encode logging event as one json object per line
+	EncoderJson = "json"
+)
+
 type encoder interface {
 	// encoding logging event to bytes
 	encode(event *LoggingEvent) []byte
 }
+
+// colour conversions of layout are ignored if isColorEnabled is false
+func newEncoder(config *AppenderConfig, isColorEnabled bool) (encoder, error) {
+	switch config.Encoder {
+	case emptyString, EncoderPattern:
+		return newPatternEncoder(config.Layout, isColorEnabled)
+	case EncoderJson:
+		return newJsonEncoder(), nil
+	}
+
+	return nil, errors.New("unsupported encoder '" + config.Encoder + "'")
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+)
+
+const (
+	// key of the value which is passed to With without a key
+	missingKey = "!BADKEY"
+)
+
+// key/value pair attached to logging event
+// it can be passed along with the values of format, like `logger.Info("user {} login", name, log.NewField("ip", ip))`
+// and it will not be used by placeholders
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func NewField(key string, value interface{}) Field {
+	return Field{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// convert alternating keys and values to fields
+func newFields(keyValues []interface{}) []Field {
+	fields := make([]Field, 0, (len(keyValues)+1)/2)
+
+	for i := 0; i < len(keyValues); {
+		switch v := keyValues[i].(type) {
+		case Field:
+			fields = append(fields, v)
+			i += 1
+		case string:
+			if i+1 < len(keyValues) {
+				fields = append(fields, NewField(v, keyValues[i+1]))
+			} else {
+				fields = append(fields, NewField(missingKey, v))
+			}
+			i += 2
+		default:
+			fields = append(fields, NewField(missingKey, v))
+			i += 1
+		}
+	}
+
+	return fields
+}
+
+// separate fields from values, the original values is returned if there is no field
+func splitFields(values []interface{}) ([]interface{}, []Field) {
+	fieldNum := 0
+	for _, value := range values {
+		if _, ok := value.(Field); ok {
+			fieldNum += 1
+		}
+	}
+	if fieldNum == 0 {
+		return values, nil
+	}
+
+	fields := make([]Field, 0, fieldNum)
+	nonFieldValues := make([]interface{}, 0, len(values)-fieldNum)
+	for _, value := range values {
+		if field, ok := value.(Field); ok {
+			fields = append(fields, field)
+		} else {
+			nonFieldValues = append(nonFieldValues, value)
+		}
+	}
+
+	return nonFieldValues, fields
+}
+
+// fields are never modified once created, so the inputs may be returned directly
+func mergeFields(fields []Field, otherFields []Field) []Field {
+	if len(otherFields) == 0 {
+		return fields
+	}
+	if len(fields) == 0 {
+		return otherFields
+	}
+
+	merged := make([]Field, 0, len(fields)+len(otherFields))
+	merged = append(merged, fields...)
+	return append(merged, otherFields...)
+}
+
+// format like `key1=value1, key2=value2`, in the order of adding
+func formatFields(fields []Field) string {
+	buffer := bytes.Buffer{}
+	for i, field := range fields {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(field.Key)
+		buffer.WriteByte('=')
+		buffer.WriteString(stringify(field.Value))
+	}
+	return buffer.String()
+}
@@ -1,10 +1,13 @@
 package log
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/liuyehcf/common-gtools/utils"
 	cr "github.com/robfig/cron/v3"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -20,73 +23,32 @@ const (
 	TimeGranularityDay  = int(2)
 	sizeRolling         = int(0)
 	timerRolling        = int(1)
-	formatDay           = "2006-01-02"
 	emptyString         = ""
 	fileSuffix          = ".log"
+	gzipSuffix          = ".gz"
+	temporarySuffix     = ".tmp"
 	pathSeparator       = string(os.PathSeparator)
 )
 
-var (
-	timeGranularityMap = map[int]string{
-		TimeGranularityHour: "@hourly",
-		TimeGranularityDay:  "@daily",
-	}
-)
-
 type fileMeta struct {
 	// exclude directory
 	abstractPath string
-	day          string
-	hour         string
-	index        string
-	dayValue     int64
-	hourValue    int
-	indexValue   int
-}
-
-func newFileMeta(abstractPath string, day string, hour string, index string) *fileMeta {
-	var dayValue int64
-	var hourValue int
-	var indexValue int
-	var err error
 
-	if day == emptyString {
-		dayValue = -1
-	} else {
-		dayTime, err := time.Parse(formatDay, day)
-		if err != nil {
-			return nil
-		}
+	// period during which events are written to the file
+	startTime time.Time
+	endTime   time.Time
+	index     int
 
-		dayValue = dayTime.Unix()
-	}
-
-	if hour == emptyString {
-		hourValue = -1
-	} else {
-		hourValue, err = strconv.Atoi(hour)
-		if err != nil {
-			return nil
-		}
-	}
-
-	if index == emptyString {
-		indexValue = -1
-	} else {
-		indexValue, err = strconv.Atoi(index)
-		if err != nil {
-			return nil
-		}
-	}
+	// size of file in bytes, set when the file is listed
+	size int64
+}
 
+func newFileMeta(abstractPath string, startTime time.Time, endTime time.Time, index int) *fileMeta {
 	return &fileMeta{
 		abstractPath: abstractPath,
-		day:          day,
-		hour:         hour,
+		startTime:    startTime,
+		endTime:      endTime,
 		index:        index,
-		dayValue:     dayValue,
-		hourValue:    hourValue,
-		indexValue:   indexValue,
 	}
 }
 
@@ -100,18 +62,12 @@ func (slice fileMetaSlice) Len() int {
 func (slice fileMetaSlice) Less(i, j int) bool {
 	left := slice[i]
 	right := slice[j]
-	if left.dayValue < right.dayValue {
+	if left.startTime.Before(right.startTime) {
 		return true
-	} else if left.dayValue > right.dayValue {
+	} else if left.startTime.After(right.startTime) {
 		return false
 	} else {
-		if left.hourValue < right.hourValue {
-			return true
-		} else if left.hourValue > right.hourValue {
-			return false
-		} else {
-			return left.indexValue <= right.indexValue
-		}
+		return left.index < right.index
 	}
 }
 
@@ -130,11 +86,37 @@ type RollingPolicy struct {
 	// only support TimeGranularityHour and TimeGranularityDay
 	TimeGranularity int
 
+	// pattern of rolled file names like `app-%d{2006-01-02_15-04}.%i.log`, which overrides TimeGranularity
+	// `%d{layout}` is the start of period formatted by go layout, `%d` alone means `%d{2006-01-02}`
+	// `%i` is the index of rolled files within the same period
+	// files roll at the finest unit of layout(minute, hour, day or month) by default,
+	// and the period can be specified like `%d{2006-01-02_15-04}{10m}` or `%d{2006-01-02}{week}`
+	FileNamePattern string
+
+	// roll by MaxFileSize only, and name rolled files `default.1.log` to `default.N.log` where N is MaxHistory,
+	// the newest file is always `default.1.log`, and indexes of others are shifted up at each rolling
+	// dates are never used, so that it works without a meaningful wall clock
+	FixedWindow bool
+
+	// allow several processes, or several appenders, to write the same file safely, like logback's prudent mode
+	// writing and rolling are guarded by an advisory lock on `default.lock`, and the file is reopened once
+	// it is rolled by others, which is slower, and only supported on unix like systems
+	Prudent bool
+
 	// maximum history of rolling logs
 	MaxHistory int
 
 	// maximum size of log file
 	MaxFileSize int64
+
+	// compress rolled files to `.log.gz` in background
+	Compress bool
+
+	// maximum total size of rolled files, the oldest ones are removed once exceeded, 0 means unlimited
+	TotalSizeCap int64
+
+	// rolled files are removed once their period is older than MaxAge, 0 means unlimited
+	MaxAge time.Duration
 }
 
 type fileAppender struct {
@@ -144,7 +126,16 @@ type fileAppender struct {
 	file             *os.File
 	fileAbstractPath string
 	fileRelativePath string
-	fileAbstractName string
+	pattern          *fileNamePattern
+
+	// whether files roll at the end of each period, besides exceeding MaxFileSize
+	isTimerRolling bool
+
+	// only set in prudent mode
+	fileLock *fileLock
+
+	// running compressions of rolled files
+	compressions *sync.WaitGroup
 }
 
 func NewFileAppender(config *AppenderConfig) (*fileAppender, error) {
@@ -158,51 +149,110 @@ func NewFileAppender(config *AppenderConfig) (*fileAppender, error) {
 		TimeGranularityDay != policy.TimeGranularity {
 		return nil, errors.New("TimeGranularity only support 0(TimeGranularityNone) or 1(TimeGranularityHour) or 2(TimeGranularityDay)")
 	}
+	if policy.FileNamePattern != emptyString && TimeGranularityNone != policy.TimeGranularity {
+		return nil, errors.New("TimeGranularity can not be used with FileNamePattern")
+	}
+	if policy.FixedWindow && (policy.FileNamePattern != emptyString || TimeGranularityNone != policy.TimeGranularity) {
+		return nil, errors.New("FixedWindow can not be used with TimeGranularity or FileNamePattern")
+	}
+	if policy.FixedWindow && policy.MaxAge > 0 {
+		return nil, errors.New("FixedWindow can not be used with MaxAge")
+	}
+	if policy.Prudent && policy.Compress {
+		return nil, errors.New("Prudent can not be used with Compress")
+	}
 	if policy.MaxHistory < 1 {
 		return nil, errors.New("MaxHistory must large than 0")
 	}
 	if policy.MaxFileSize < 1 {
 		return nil, errors.New("MaxFileSize must large than 0")
 	}
+	if policy.TotalSizeCap < 0 {
+		return nil, errors.New("TotalSizeCap must not be negative")
+	}
+	if policy.MaxAge < 0 {
+		return nil, errors.New("MaxAge must not be negative")
+	}
 
 	for strings.HasSuffix(policy.Directory, pathSeparator) {
 		size := len(policy.Directory)
 		policy.Directory = policy.Directory[0 : size-1]
 	}
 
+	var pattern *fileNamePattern
+	var err error
+	// fixed window has its own naming without dates
+	if policy.FileNamePattern != emptyString {
+		pattern, err = newFileNamePattern(policy.FileNamePattern)
+	} else if !policy.FixedWindow {
+		pattern, err = newFileNamePattern(policy.FileName + timeGranularityPatterns[policy.TimeGranularity])
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	fileRelativePath := policy.FileName + fileSuffix
-	encoder, err := newPatternEncoder(config.Layout)
+	encoder, err := newEncoder(config, false)
+	if err != nil {
+		return nil, err
+	}
+	abstractAppender, err := newAbstractAppender(config, encoder)
 	if err != nil {
 		return nil, err
 	}
 	appender := &fileAppender{
-		abstractAppender: abstractAppender{
-			encoder: encoder,
-			filters: config.Filters,
-			lock:    new(sync.Mutex),
-			queue:   make(chan []byte, 1024),
-		},
+		abstractAppender: abstractAppender,
 		policy:           policy,
 		cron:             cr.New(),
 		fileRelativePath: fileRelativePath,
 		fileAbstractPath: policy.Directory + pathSeparator + fileRelativePath,
-		fileAbstractName: policy.Directory + pathSeparator + policy.FileName,
+		pattern:          pattern,
+		isTimerRolling:   policy.FileNamePattern != emptyString || TimeGranularityNone != policy.TimeGranularity,
+		compressions:     new(sync.WaitGroup),
 	}
 
-	appender.cron.Start()
 	err = appender.createDirectoryIfNecessary()
 	if err != nil {
 		return nil, err
 	}
+
+	// the file and the lock are released if the appender fails to be created
+	isCreated := false
+	defer func() {
+		if !isCreated {
+			appender.closeFileLock()
+			if appender.file != nil {
+				_ = appender.file.Close()
+			}
+		}
+	}()
+
+	if policy.Prudent {
+		appender.fileLock, err = openFileLock(policy.Directory + pathSeparator + policy.FileName + lockSuffix)
+		if err != nil {
+			return nil, err
+		}
+	}
 	err = appender.openOrCreateFile()
 	if err != nil {
 		return nil, err
 	}
 
-	timeGranularity, exist := timeGranularityMap[policy.TimeGranularity]
-	if exist {
-		_, err := appender.cron.AddFunc(timeGranularity, func() {
-			appender.createFileIfNecessary()
+	// rolled files left by previous processes may exceed the limits, or be left by interrupted compressions
+	appender.lockFile()
+	appender.cleanRolledFiles()
+	appender.recoverCompressions()
+	appender.unlockFile()
+
+	if appender.isTimerRolling {
+		_, err := appender.cron.AddFunc(pattern.period.getCronSpec(), func() {
+			appender.lockFile()
+			defer appender.unlockFile()
+
+			// in prudent mode, the replaced file has already been rolled by others
+			if appender.createFileIfNecessary() && policy.Prudent {
+				return
+			}
 			appender.rollingByTimer()
 		})
 		if err != nil {
@@ -210,82 +260,227 @@ func NewFileAppender(config *AppenderConfig) (*fileAppender, error) {
 		}
 	}
 
-	go appender.onEventLoop()
+	err = appender.Start()
+	if err != nil {
+		return nil, err
+	}
 
+	isCreated = true
 	return appender, nil
 }
 
-func (appender *fileAppender) Destroy() {
-	lock.Lock()
-	defer lock.Unlock()
-	appender.isDestroyed = true
-	executeIgnorePanic(func() {
-		appender.cron.Stop()
-	})
-	executeIgnorePanic(func() {
-		_ = appender.file.Close()
-	})
-	executeIgnorePanic(func() {
-		close(appender.queue)
+func (appender *fileAppender) Start() error {
+	return appender.start(func() {
+		appender.cron.Start()
+		go appender.onEventLoop()
 	})
 }
 
+// stop accepting events, the queued events are still written, and the file is closed after that
+func (appender *fileAppender) Stop() {
+	if appender.stop(appender.onEventLoop) {
+		appender.cron.Stop()
+	}
+}
+
+func (appender *fileAppender) Destroy() {
+	appender.Stop()
+}
+
+func (appender *fileAppender) flush(ctx context.Context) bool {
+	if !appender.waitDrained(ctx) {
+		return false
+	}
+	appender.sync()
+	return true
+}
+
 func (appender *fileAppender) onEventLoop() {
+	defer close(appender.stopped)
+	defer appender.closeFile()
 	defer func() {
 		recover()
 	}()
 
-	var content []byte
-	var ok bool
-	for !appender.isDestroyed {
-		if content, ok = <-appender.queue; !ok {
-			// channel is closed
-			break
-		}
+	// the queued events are drained until the channel is closed
+	for content := range appender.queue {
+		appender.lockFile()
 		appender.createFileIfNecessary()
 		appender.rollingIfFileSizeExceeded()
 		appender.write(content)
+		appender.unlockFile()
+		appender.onWritten()
 	}
 }
 
+// called after the event loop exits, wait for the running rolling job and compressions before closing
+func (appender *fileAppender) closeFile() {
+	<-appender.cron.Stop().Done()
+	appender.compressions.Wait()
+
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+	_ = appender.file.Sync()
+	_ = appender.file.Close()
+	appender.closeFileLock()
+}
+
+func (appender *fileAppender) sync() {
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+	_ = appender.file.Sync()
+}
+
 func (appender *fileAppender) rollingIfFileSizeExceeded() {
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+
 	info, err := appender.file.Stat()
 	if err != nil {
 		return
 	}
 
-	appender.lock.Lock()
-	defer appender.lock.Unlock()
-
 	if info.Size() >= appender.policy.MaxFileSize {
 		appender.doRolling(sizeRolling)
 	}
 }
 
 func (appender *fileAppender) rollingByTimer() {
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+
 	info, err := appender.file.Stat()
 	if err != nil {
 		return
 	}
 
-	appender.lock.Lock()
-	defer appender.lock.Unlock()
-
 	if info.Size() > 0 {
 		appender.doRolling(timerRolling)
 	}
 }
 
 func (appender *fileAppender) doRolling(rollingType int) {
-	fileMetas := appender.getAllRollingFileMetas()
+	if appender.policy.FixedWindow {
+		appender.doFixedWindowRolling()
+		return
+	}
+
+	// file rolled by timer belongs to the period just ended
+	startTime := appender.pattern.period.getStartTime(time.Now())
+	if rollingType == timerRolling {
+		startTime = appender.pattern.period.getStartTime(startTime.Add(-time.Nanosecond))
+	}
+
+	allRollingFileMetas := fileMetaSlice(appender.getAllRollingFileMetas())
+	policy := appender.policy
+
+	if len(allRollingFileMetas) >= policy.MaxHistory {
+		appender.sortFromOldest(allRollingFileMetas)
+		maxRemainHistory := policy.MaxHistory - 1
+		removedFileMetas := allRollingFileMetas[:len(allRollingFileMetas)-maxRemainHistory]
+
+		for _, removedFileMeta := range removedFileMetas {
+			_ = os.Remove(removedFileMeta.abstractPath)
+		}
+
+		allRollingFileMetas = allRollingFileMetas[len(allRollingFileMetas)-maxRemainHistory:]
+	}
 
-	switch appender.policy.TimeGranularity {
-	case TimeGranularityHour:
-		appender.rollingFilesByHourGranularity(rollingType, fileMetas)
-		break
-	case TimeGranularityNone, TimeGranularityDay:
-		appender.rollingFilesByDayGranularity(rollingType, fileMetas)
-		break
+	latestIndex := 0
+	for _, fileMeta := range allRollingFileMetas {
+		if fileMeta.startTime.Equal(startTime) && fileMeta.index > latestIndex {
+			latestIndex = fileMeta.index
+		}
+	}
+
+	_ = appender.file.Close()
+
+	rolledPath := policy.Directory + pathSeparator + appender.pattern.format(startTime, latestIndex+1)
+	if os.Rename(appender.fileAbstractPath, rolledPath) == nil {
+		appender.compressIfNecessary(rolledPath)
+	}
+
+	_ = appender.openOrCreateFile()
+
+	appender.cleanRolledFiles()
+}
+
+// shift indexes of rolled files up, and the current file becomes the first one
+// files whose index reaches MaxHistory are removed
+func (appender *fileAppender) doFixedWindowRolling() {
+	// compressing files can not be renamed
+	appender.compressions.Wait()
+
+	allRollingFileMetas := fileMetaSlice(appender.getAllRollingFileMetas())
+	appender.sortFromOldest(allRollingFileMetas)
+
+	policy := appender.policy
+	for _, fileMeta := range allRollingFileMetas {
+		if fileMeta.index >= policy.MaxHistory {
+			_ = os.Remove(fileMeta.abstractPath)
+			continue
+		}
+
+		suffix := emptyString
+		if strings.HasSuffix(fileMeta.abstractPath, gzipSuffix) {
+			suffix = gzipSuffix
+		}
+		_ = os.Rename(fileMeta.abstractPath, appender.getFixedWindowPath(fileMeta.index+1)+suffix)
+	}
+
+	_ = appender.file.Close()
+
+	rolledPath := appender.getFixedWindowPath(1)
+	if os.Rename(appender.fileAbstractPath, rolledPath) == nil {
+		appender.compressIfNecessary(rolledPath)
+	}
+
+	_ = appender.openOrCreateFile()
+
+	appender.cleanRolledFiles()
+}
+
+func (appender *fileAppender) getFixedWindowPath(index int) string {
+	return fmt.Sprintf("%s%s%s.%d%s", appender.policy.Directory, pathSeparator, appender.policy.FileName, index, fileSuffix)
+}
+
+// files of larger index are older in fixed window
+func (appender *fileAppender) sortFromOldest(fileMetas fileMetaSlice) {
+	if appender.policy.FixedWindow {
+		sort.Sort(sort.Reverse(fileMetas))
+	} else {
+		sort.Sort(fileMetas)
+	}
+}
+
+// remove the rolled files older than MaxAge, and then the oldest ones until their total size fits TotalSizeCap
+func (appender *fileAppender) cleanRolledFiles() {
+	policy := appender.policy
+	if policy.MaxAge == 0 && policy.TotalSizeCap == 0 {
+		return
+	}
+
+	fileMetas := fileMetaSlice(appender.getAllRollingFileMetas())
+	appender.sortFromOldest(fileMetas)
+
+	if policy.MaxAge > 0 {
+		expiredTime := time.Now().Add(-policy.MaxAge)
+		for ; len(fileMetas) > 0 && fileMetas[0].endTime.Before(expiredTime); {
+			_ = os.Remove(fileMetas[0].abstractPath)
+			fileMetas = fileMetas[1:]
+		}
+	}
+
+	if policy.TotalSizeCap > 0 {
+		var totalSize int64
+		for _, fileMeta := range fileMetas {
+			totalSize += fileMeta.size
+		}
+		for ; len(fileMetas) > 0 && totalSize > policy.TotalSizeCap; {
+			_ = os.Remove(fileMetas[0].abstractPath)
+			totalSize -= fileMetas[0].size
+			fileMetas = fileMetas[1:]
+		}
 	}
 }
 
@@ -297,14 +492,21 @@ func (appender *fileAppender) getAllRollingFileMetas() []*fileMeta {
 		return fileMetas
 	}
 
+	names := make(map[string]bool, len(files))
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), appender.policy.FileName) &&
-			strings.HasSuffix(file.Name(), fileSuffix) {
-			fileMeta := appender.parseRollingFileInfo(file)
+		names[file.Name()] = true
+	}
 
-			if utils.IsNotNil(fileMeta) {
-				fileMetas = append(fileMetas, fileMeta)
-			}
+	for _, file := range files {
+		// the rolled file is removed soon once it is compressed, so it is not counted twice
+		if names[file.Name()+gzipSuffix] {
+			continue
+		}
+
+		fileMeta := appender.parseRollingFileInfo(file)
+		if utils.IsNotNil(fileMeta) {
+			fileMeta.size = file.Size()
+			fileMetas = append(fileMetas, fileMeta)
 		}
 	}
 
@@ -312,146 +514,177 @@ func (appender *fileAppender) getAllRollingFileMetas() []*fileMeta {
 }
 
 func (appender *fileAppender) parseRollingFileInfo(fileInfo os.FileInfo) *fileMeta {
-	abstractPath := appender.policy.Directory + pathSeparator + fileInfo.Name()
-
-	// skip current file
-	if fileInfo.Name() == appender.fileRelativePath {
+	if fileInfo.IsDir() {
 		return nil
 	}
+	return appender.parseRollingFileName(fileInfo.Name())
+}
 
-	segments := strings.Split(fileInfo.Name(), ".")
-	if segments == nil {
+func (appender *fileAppender) parseRollingFileName(name string) *fileMeta {
+	abstractPath := appender.policy.Directory + pathSeparator + name
+
+	// skip current file
+	if name == appender.fileRelativePath {
 		return nil
 	}
-	segmentLen := len(segments)
 
-	switch appender.policy.TimeGranularity {
-	case TimeGranularityHour:
-		// xxx.2006-01-02.08.1.log
-		if segmentLen == 5 {
-			return newFileMeta(abstractPath, segments[1], segments[2], segments[3])
-		} else {
+	if appender.policy.FixedWindow {
+		// xxx.1.log
+		segments := strings.Split(strings.TrimSuffix(name, gzipSuffix), ".")
+		if len(segments) != 3 || segments[0] != appender.policy.FileName || "."+segments[2] != fileSuffix {
 			return nil
 		}
-	case TimeGranularityNone, TimeGranularityDay:
-		// xxx.2006-01-02.1.log
-		if segmentLen == 4 {
-			return newFileMeta(abstractPath, segments[1], "", segments[2])
-		} else {
+		index, err := strconv.Atoi(segments[1])
+		if err != nil || index < 1 {
 			return nil
 		}
+		return newFileMeta(abstractPath, time.Time{}, time.Time{}, index)
 	}
 
-	return nil
+	// compressed file has the same pattern as the rolled one
+	startTime, index, ok := appender.pattern.parse(name)
+	if !ok {
+		return nil
+	}
+
+	return newFileMeta(abstractPath, startTime, appender.pattern.period.getEndTime(startTime), index)
 }
 
-func (appender *fileAppender) rollingFilesByHourGranularity(rollingType int, allRollingFileMetas fileMetaSlice) {
-	var t time.Time
-	if rollingType == timerRolling {
-		t = time.Now().Add(-time.Hour)
-	} else {
-		t = time.Now()
+// compressions interrupted by a crash leave temporary files, and rolled files which are either uncompressed
+// or already compressed, the temporary files are removed, and the rolled files are compressed again or removed
+func (appender *fileAppender) recoverCompressions() {
+	files, err := ioutil.ReadDir(appender.policy.Directory)
+	if err != nil {
+		return
 	}
-	dayFormatted := t.Format(formatDay)
-	dayTime, _ := time.Parse(formatDay, dayFormatted)
-	day := dayTime.Unix()
-	hour := t.Hour()
 
-	policy := appender.policy
+	names := make(map[string]bool, len(files))
+	for _, file := range files {
+		names[file.Name()] = true
+	}
 
-	if len(allRollingFileMetas) >= policy.MaxHistory {
-		sort.Sort(allRollingFileMetas)
-		maxRemainHistory := policy.MaxHistory - 1
-		removedFileMetas := allRollingFileMetas[:len(allRollingFileMetas)-maxRemainHistory]
+	for _, file := range files {
+		name := file.Name()
+		path := appender.policy.Directory + pathSeparator + name
 
-		for _, removedFileMeta := range removedFileMetas {
-			_ = os.Remove(removedFileMeta.abstractPath)
+		if strings.HasSuffix(name, gzipSuffix+temporarySuffix) {
+			if !file.IsDir() && utils.IsNotNil(appender.parseRollingFileName(strings.TrimSuffix(name, temporarySuffix))) {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		if strings.HasSuffix(name, gzipSuffix) || utils.IsNil(appender.parseRollingFileInfo(file)) {
+			continue
 		}
 
-		allRollingFileMetas = allRollingFileMetas[len(allRollingFileMetas)-maxRemainHistory:]
-	}
-
-	fileMetasOfCurHour := make(fileMetaSlice, 0)
-
-	for _, fileMeta := range allRollingFileMetas {
-		if fileMeta.dayValue == day &&
-			fileMeta.hourValue == hour {
-			fileMetasOfCurHour = append(fileMetasOfCurHour, fileMeta)
+		// the compressed file is renamed from the temporary one once completed
+		if names[name+gzipSuffix] {
+			_ = os.Remove(path)
+			continue
 		}
+		appender.compressIfNecessary(path)
 	}
+}
 
-	latestIndex := 0
-	if len(fileMetasOfCurHour) > 0 {
-		sort.Sort(fileMetasOfCurHour)
-		latestFileMeta := fileMetasOfCurHour[len(fileMetasOfCurHour)-1]
-		latestIndex = latestFileMeta.indexValue
+// compress rolled file to `.log.gz` in background, so that writing is not blocked
+func (appender *fileAppender) compressIfNecessary(path string) {
+	if !appender.policy.Compress {
+		return
 	}
 
-	_ = appender.file.Close()
-
-	_ = os.Rename(appender.fileAbstractPath,
-		fmt.Sprintf("%s.%s.%02d.%d%s", appender.fileAbstractName, dayFormatted, hour, latestIndex+1, fileSuffix))
-
-	_ = appender.openOrCreateFile()
+	appender.compressions.Add(1)
+	go func() {
+		defer appender.compressions.Done()
+		_ = compressFile(path)
+	}()
 }
 
-func (appender *fileAppender) rollingFilesByDayGranularity(rollingType int, allRollingFileMetas fileMetaSlice) {
-	var t time.Time
-	if rollingType == timerRolling {
-		t = time.Now().Add(-24 * time.Hour)
-	} else {
-		t = time.Now()
+// the compressed file is written to a temporary file first, so that a partial one is never taken as a rolled file
+// the source file is removed after that
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
 	}
-	dayFormatted := t.Format(formatDay)
-	dayTime, _ := time.Parse(formatDay, dayFormatted)
-	day := dayTime.Unix()
-
-	policy := appender.policy
-
-	if len(allRollingFileMetas) >= policy.MaxHistory {
-		sort.Sort(allRollingFileMetas)
-		maxRemainHistory := policy.MaxHistory - 1
-		removedFileMetas := allRollingFileMetas[:len(allRollingFileMetas)-maxRemainHistory]
-
-		for _, removedFileMeta := range removedFileMetas {
-			_ = os.Remove(removedFileMeta.abstractPath)
-		}
+	defer source.Close()
 
-		allRollingFileMetas = allRollingFileMetas[len(allRollingFileMetas)-maxRemainHistory:]
+	compressedPath := path + gzipSuffix
+	temporaryPath := compressedPath + temporarySuffix
+	target, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
 	}
 
-	fileMetasOfCurDay := make(fileMetaSlice, 0)
-
-	for _, fileMeta := range allRollingFileMetas {
-		if fileMeta.dayValue == day {
-			fileMetasOfCurDay = append(fileMetasOfCurDay, fileMeta)
-		}
+	writer := gzip.NewWriter(target)
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
 	}
-
-	latestIndex := 0
-	if len(fileMetasOfCurDay) > 0 {
-		sort.Sort(fileMetasOfCurDay)
-		latestFileMeta := fileMetasOfCurDay[len(fileMetasOfCurDay)-1]
-		latestIndex = latestFileMeta.indexValue
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, compressedPath)
+	}
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
 	}
 
-	_ = appender.file.Close()
-
-	_ = os.Rename(appender.fileAbstractPath,
-		fmt.Sprintf("%s.%s.%d%s", appender.fileAbstractName, dayFormatted, latestIndex+1, fileSuffix))
-
-	_ = appender.openOrCreateFile()
+	// the source file may be removed by history cleanup meanwhile, then the compressed one is useless as well
+	if err = os.Remove(path); err != nil {
+		_ = os.Remove(compressedPath)
+		return err
+	}
+	return nil
 }
 
 func (appender *fileAppender) createDirectoryIfNecessary() error {
 	return os.MkdirAll(appender.policy.Directory, os.ModePerm)
 }
 
-func (appender *fileAppender) createFileIfNecessary() {
-	_, err := os.Stat(appender.fileAbstractPath)
+// called by both event loop and rolling job, so the file is replaced with lock held
+// return true if the file is reopened
+func (appender *fileAppender) createFileIfNecessary() bool {
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+
+	info, err := os.Stat(appender.fileAbstractPath)
 	// fd still can be operated while file already removed by other process
 	if err != nil {
+		_ = appender.file.Close()
 		_ = appender.openOrCreateFile()
+		return true
+	}
+
+	// in prudent mode, the file may be renamed by other processes, and a new one is created at the same path
+	if appender.policy.Prudent {
+		current, err := appender.file.Stat()
+		if err != nil || !os.SameFile(info, current) {
+			_ = appender.file.Close()
+			_ = appender.openOrCreateFile()
+			return true
+		}
+	}
+	return false
+}
+
+// lock shared with other processes in prudent mode, which is taken before the lock of appender
+func (appender *fileAppender) lockFile() {
+	if appender.fileLock != nil {
+		appender.fileLock.lock()
+	}
+}
+
+func (appender *fileAppender) unlockFile() {
+	if appender.fileLock != nil {
+		appender.fileLock.unlock()
+	}
+}
+
+func (appender *fileAppender) closeFileLock() {
+	if appender.fileLock != nil {
+		appender.fileLock.close()
 	}
 }
 
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+const (
+	lockSuffix = ".lock"
+)
+
+// advisory lock shared by processes writing the same file, the lock is taken on a separate file,
+// since the file being written is renamed by rolling
+// flock does not exclude goroutines of the same process, so it is guarded by a mutex as well
+type fileLock struct {
+	file  *os.File
+	mutex *sync.Mutex
+}
+
+func openFileLock(path string) (*fileLock, error) {
+	if !isFileLockSupported {
+		return nil, errors.New("file lock is not supported on this platform")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileLock{
+		file:  file,
+		mutex: new(sync.Mutex),
+	}, nil
+}
+
+// errors are ignored, and events are still written without lock
+func (lock *fileLock) lock() {
+	lock.mutex.Lock()
+	_ = lockFile(lock.file)
+}
+
+func (lock *fileLock) unlock() {
+	_ = unlockFile(lock.file)
+	lock.mutex.Unlock()
+}
+
+func (lock *fileLock) close() {
+	_ = lock.file.Close()
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package log
+
+import (
+	"errors"
+	"os"
+)
+
+const (
+	isFileLockSupported = false
+)
+
+func lockFile(file *os.File) error {
+	return errors.New("file lock is not supported on this platform")
+}
+
+func unlockFile(file *os.File) error {
+	return errors.New("file lock is not supported on this platform")
+}
@@ -29,7 +29,9 @@ func TestDayDiff(t *testing.T) {
 		unix := time.Unix(t, 0)
 		dayString := unix.Format(dayFormat)
 		dayStrings = append(dayStrings, dayString)
-		metas = append(metas, newFileMeta("/test", dayString, "01", "1"))
+		dayTime, err := time.ParseInLocation(dayFormat, dayString, time.Local)
+		utils.AssertNil(err, "test")
+		metas = append(metas, newFileMeta("/test", dayTime, dayTime.AddDate(0, 0, 1), 1))
 	}
 	utils.AssertTrue(len(metas) > 3650, "test")
 	fmt.Printf("day num=%d\n", len(metas))
@@ -41,7 +43,7 @@ func TestDayDiff(t *testing.T) {
 	sort.Sort(metas)
 
 	for i := 0; i < len(metas); i += 1 {
-		utils.AssertTrue(metas[i].day == dayStrings[i], "test")
+		utils.AssertTrue(metas[i].startTime.Format(dayFormat) == dayStrings[i], "test")
 	}
 }
 
@@ -67,7 +69,8 @@ func TestHourDiff(t *testing.T) {
 		hourString := fmt.Sprintf("%02d", dayHourTime.Hour())
 		hourStrings = append(hourStrings, hourString)
 
-		metas = append(metas, newFileMeta("/test", dayString, hourString, "1"))
+		hourTime := time.Date(dayHourTime.Year(), dayHourTime.Month(), dayHourTime.Day(), dayHourTime.Hour(), 0, 0, 0, time.Local)
+		metas = append(metas, newFileMeta("/test", hourTime, hourTime.Add(time.Hour), 1))
 	}
 	utils.AssertTrue(len(metas) > 240, "test")
 	fmt.Printf("hour num=%d\n", len(metas))
@@ -79,8 +82,8 @@ func TestHourDiff(t *testing.T) {
 	sort.Sort(metas)
 
 	for i := 0; i < len(metas); i += 1 {
-		utils.AssertTrue(metas[i].day == dayStrings[i], "test")
-		utils.AssertTrue(metas[i].hour == hourStrings[i], "test")
+		utils.AssertTrue(metas[i].startTime.Format(dayFormat) == dayStrings[i], "test")
+		utils.AssertTrue(fmt.Sprintf("%02d", metas[i].startTime.Hour()) == hourStrings[i], "test")
 	}
 }
 
@@ -90,7 +93,8 @@ func TestIndexDiff(t *testing.T) {
 
 	metas := make(fileMetaSlice, 0)
 	for t := fromIndex; t <= toIndex; t += 1 {
-		metas = append(metas, newFileMeta("/test", "2020-01-02", "01", fmt.Sprintf("%d", t)))
+		hourTime := time.Date(2020, 1, 2, 1, 0, 0, 0, time.Local)
+		metas = append(metas, newFileMeta("/test", hourTime, hourTime.Add(time.Hour), t))
 	}
 	fmt.Printf("index num=%d\n", len(metas))
 
@@ -101,7 +105,7 @@ func TestIndexDiff(t *testing.T) {
 	sort.Sort(metas)
 
 	for i := 0; i < len(metas); i += 1 {
-		utils.AssertTrue(metas[i].indexValue == i+1, "test")
+		utils.AssertTrue(metas[i].index == i+1, "test")
 	}
 }
 
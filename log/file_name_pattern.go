@@ -0,0 +1,322 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	periodMinute = int(0)
+	periodHour   = int(1)
+	periodDay    = int(2)
+	periodWeek   = int(3)
+	periodMonth  = int(4)
+
+	segmentLiteral = int(0)
+	segmentDate    = int(1)
+	segmentIndex   = int(2)
+
+	defaultDateLayout = "2006-01-02"
+)
+
+var (
+	periodUnits = map[string]int{
+		"minute": periodMinute,
+		"hour":   periodHour,
+		"day":    periodDay,
+		"week":   periodWeek,
+		"month":  periodMonth,
+	}
+
+	// patterns of rolled files for TimeGranularity, which are appended to FileName
+	timeGranularityPatterns = map[int]string{
+		TimeGranularityNone: ".%d{2006-01-02}.%i.log",
+		TimeGranularityHour: ".%d{2006-01-02.15}.%i.log",
+		TimeGranularityDay:  ".%d{2006-01-02}.%i.log",
+	}
+
+	// times used to check whether date layout is able to represent a period
+	referenceTimes = []time.Time{
+		time.Date(2001, 2, 3, 4, 5, 6, 0, time.Local),
+		time.Date(2019, 12, 31, 23, 59, 59, 0, time.Local),
+	}
+)
+
+// period of time based rolling, which is aligned to the local midnight, monday or the first day of month
+type rollingPeriod struct {
+	unit  int
+	count int
+}
+
+func (period *rollingPeriod) getStartTime(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch period.unit {
+	case periodMinute:
+		minute := t.Minute() - t.Minute()%period.count
+		return time.Date(year, month, day, t.Hour(), minute, 0, 0, t.Location())
+	case periodHour:
+		hour := t.Hour() - t.Hour()%period.count
+		return time.Date(year, month, day, hour, 0, 0, 0, t.Location())
+	case periodWeek:
+		// weeks start on monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case periodMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// end of the period starting at start, which is also the start of the next one
+func (period *rollingPeriod) getEndTime(start time.Time) time.Time {
+	switch period.unit {
+	case periodMinute:
+		return start.Add(time.Duration(period.count) * time.Minute)
+	case periodHour:
+		return start.Add(time.Duration(period.count) * time.Hour)
+	case periodWeek:
+		return start.AddDate(0, 0, 7)
+	case periodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// cron spec triggered at the start of each period
+func (period *rollingPeriod) getCronSpec() string {
+	switch period.unit {
+	case periodMinute:
+		return fmt.Sprintf("*/%d * * * *", period.count)
+	case periodHour:
+		return fmt.Sprintf("0 */%d * * *", period.count)
+	case periodWeek:
+		return "0 0 * * 1"
+	case periodMonth:
+		return "0 0 1 * *"
+	default:
+		return "0 0 * * *"
+	}
+}
+
+// `minute`, `hour`, `day`, `week`, `month`, or duration like `10m` and `6h`
+// which must divide an hour or a day evenly, so that periods are aligned to the midnight
+func parsePeriod(value string) (*rollingPeriod, error) {
+	if unit, ok := periodUnits[strings.ToLower(value)]; ok {
+		return &rollingPeriod{unit: unit, count: 1}, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported period '%s'", value)
+	}
+	if duration >= time.Minute && duration < time.Hour && duration%time.Minute == 0 && time.Hour%duration == 0 {
+		return &rollingPeriod{unit: periodMinute, count: int(duration / time.Minute)}, nil
+	}
+	if duration >= time.Hour && duration <= 24*time.Hour && duration%time.Hour == 0 && (24*time.Hour)%duration == 0 {
+		if duration == 24*time.Hour {
+			return &rollingPeriod{unit: periodDay, count: 1}, nil
+		}
+		return &rollingPeriod{unit: periodHour, count: int(duration / time.Hour)}, nil
+	}
+	return nil, fmt.Errorf("period '%s' must divide an hour or a day evenly", value)
+}
+
+// finest unit of minute, hour, day and month represented by layout
+func getLayoutPeriod(layout string) (*rollingPeriod, error) {
+	t := referenceTimes[0]
+	formatted := t.Format(layout)
+
+	if t.Add(time.Minute).Format(layout) != formatted {
+		return &rollingPeriod{unit: periodMinute, count: 1}, nil
+	}
+	if t.Add(time.Hour).Format(layout) != formatted {
+		return &rollingPeriod{unit: periodHour, count: 1}, nil
+	}
+	if t.AddDate(0, 0, 1).Format(layout) != formatted {
+		return &rollingPeriod{unit: periodDay, count: 1}, nil
+	}
+	if t.AddDate(0, 1, 0).Format(layout) != formatted {
+		return &rollingPeriod{unit: periodMonth, count: 1}, nil
+	}
+	return nil, fmt.Errorf("date layout '%s' must contain month at least", layout)
+}
+
+type patternSegment struct {
+	kind int
+	text string
+}
+
+// pattern of rolled file names like `app-%d{2006-01-02_15-04}.%i.log`, where `%d{layout}` is the start of period
+// formatted by go layout, and `%i` is the index of rolled files within the same period
+// period is the finest unit of layout by default, and can be specified like `%d{2006-01-02_15-04}{10m}`
+type fileNamePattern struct {
+	segments []*patternSegment
+	layout   string
+	period   *rollingPeriod
+	regex    *regexp.Regexp
+
+	// positions of submatches
+	dateGroup  int
+	indexGroup int
+}
+
+func newFileNamePattern(pattern string) (*fileNamePattern, error) {
+	if strings.Contains(pattern, pathSeparator) || strings.Contains(pattern, "/") {
+		return nil, errors.New("file name pattern must not contain path separator")
+	}
+	if strings.HasSuffix(pattern, gzipSuffix) {
+		return nil, errors.New("file name pattern must not end with '.gz', use Compress instead")
+	}
+
+	fileNamePattern := &fileNamePattern{
+		segments: make([]*patternSegment, 0),
+	}
+
+	var periodValue string
+	literal := new(strings.Builder)
+	for ; len(pattern) > 0; {
+		if !strings.HasPrefix(pattern, "%d") && !strings.HasPrefix(pattern, "%i") {
+			literal.WriteByte(pattern[0])
+			pattern = pattern[1:]
+			continue
+		}
+
+		if literal.Len() > 0 {
+			fileNamePattern.segments = append(fileNamePattern.segments, &patternSegment{kind: segmentLiteral, text: literal.String()})
+			literal.Reset()
+		}
+
+		if strings.HasPrefix(pattern, "%i") {
+			if fileNamePattern.indexGroup > 0 {
+				return nil, errors.New("file name pattern contains more than one '%i'")
+			}
+			fileNamePattern.segments = append(fileNamePattern.segments, &patternSegment{kind: segmentIndex})
+			fileNamePattern.indexGroup = fileNamePattern.dateGroup + 1
+			pattern = pattern[2:]
+			continue
+		}
+
+		if fileNamePattern.dateGroup > 0 {
+			return nil, errors.New("file name pattern contains more than one '%d'")
+		}
+		pattern = pattern[2:]
+
+		layout, rest, err := getBracedOption(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if layout == emptyString {
+			layout = defaultDateLayout
+		}
+		pattern = rest
+
+		periodValue, pattern, err = getBracedOption(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		fileNamePattern.layout = layout
+		fileNamePattern.segments = append(fileNamePattern.segments, &patternSegment{kind: segmentDate})
+		fileNamePattern.dateGroup = fileNamePattern.indexGroup + 1
+	}
+	if literal.Len() > 0 {
+		fileNamePattern.segments = append(fileNamePattern.segments, &patternSegment{kind: segmentLiteral, text: literal.String()})
+	}
+
+	if fileNamePattern.dateGroup == 0 {
+		return nil, errors.New("file name pattern must contain '%d'")
+	}
+	if fileNamePattern.indexGroup == 0 {
+		return nil, errors.New("file name pattern must contain '%i'")
+	}
+
+	var err error
+	if periodValue == emptyString {
+		fileNamePattern.period, err = getLayoutPeriod(fileNamePattern.layout)
+	} else {
+		fileNamePattern.period, err = parsePeriod(periodValue)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// start of each period must be recovered from file name, otherwise rolled files can not be ordered
+	for _, t := range referenceTimes {
+		start := fileNamePattern.period.getStartTime(t)
+		parsed, err := time.ParseInLocation(fileNamePattern.layout, start.Format(fileNamePattern.layout), time.Local)
+		if err != nil || !parsed.Equal(start) {
+			return nil, fmt.Errorf("date layout '%s' can not represent the start of period", fileNamePattern.layout)
+		}
+	}
+
+	expression := new(strings.Builder)
+	expression.WriteString("^")
+	for _, segment := range fileNamePattern.segments {
+		switch segment.kind {
+		case segmentLiteral:
+			expression.WriteString(regexp.QuoteMeta(segment.text))
+		case segmentDate:
+			expression.WriteString("(.+?)")
+		case segmentIndex:
+			expression.WriteString(`(\d+)`)
+		}
+	}
+	expression.WriteString("(?:" + regexp.QuoteMeta(gzipSuffix) + ")?$")
+	fileNamePattern.regex = regexp.MustCompile(expression.String())
+
+	return fileNamePattern, nil
+}
+
+// content of `{...}` at the beginning of pattern, and the rest of pattern
+func getBracedOption(pattern string) (string, string, error) {
+	if !strings.HasPrefix(pattern, "{") {
+		return emptyString, pattern, nil
+	}
+
+	end := strings.Index(pattern, "}")
+	if end < 0 {
+		return emptyString, emptyString, errors.New("file name pattern contains unclosed '{'")
+	}
+	return pattern[1:end], pattern[end+1:], nil
+}
+
+// file name of the index-th rolled file of the period starting at start
+func (pattern *fileNamePattern) format(start time.Time, index int) string {
+	name := new(strings.Builder)
+	for _, segment := range pattern.segments {
+		switch segment.kind {
+		case segmentLiteral:
+			name.WriteString(segment.text)
+		case segmentDate:
+			name.WriteString(start.Format(pattern.layout))
+		case segmentIndex:
+			name.WriteString(strconv.Itoa(index))
+		}
+	}
+	return name.String()
+}
+
+// start of period and index of rolled file, or compressed one, false if name does not match the pattern
+func (pattern *fileNamePattern) parse(name string) (time.Time, int, bool) {
+	submatches := pattern.regex.FindStringSubmatch(name)
+	if submatches == nil {
+		return time.Time{}, 0, false
+	}
+
+	start, err := time.ParseInLocation(pattern.layout, submatches[pattern.dateGroup], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	index, err := strconv.Atoi(submatches[pattern.indexGroup])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return start, index, true
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestTimeGranularityPatterns(t *testing.T) {
+	pattern, err := newFileNamePattern("test" + timeGranularityPatterns[TimeGranularityHour])
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.unit == periodHour, "test")
+	utils.AssertTrue(pattern.period.getCronSpec() == "0 */1 * * *", "test")
+
+	start := time.Date(2020, 1, 2, 8, 0, 0, 0, time.Local)
+	utils.AssertTrue(pattern.format(start, 3) == "test.2020-01-02.08.3.log", "test")
+	utils.AssertTrue(pattern.period.getEndTime(start).Equal(time.Date(2020, 1, 2, 9, 0, 0, 0, time.Local)), "test")
+
+	parsed, index, ok := pattern.parse("test.2020-01-02.23.12.log.gz")
+	utils.AssertTrue(ok, "test")
+	utils.AssertTrue(parsed.Equal(time.Date(2020, 1, 2, 23, 0, 0, 0, time.Local)), "test")
+	utils.AssertTrue(index == 12, "test")
+	utils.AssertTrue(pattern.period.getEndTime(parsed).Equal(time.Date(2020, 1, 3, 0, 0, 0, 0, time.Local)), "test")
+
+	pattern, err = newFileNamePattern("test" + timeGranularityPatterns[TimeGranularityDay])
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.unit == periodDay, "test")
+
+	parsed, index, ok = pattern.parse("test.2020-01-02.1.log")
+	utils.AssertTrue(ok, "test")
+	utils.AssertTrue(pattern.period.getEndTime(parsed).Equal(time.Date(2020, 1, 3, 0, 0, 0, 0, time.Local)), "test")
+
+	// files of other granularity or other appenders are not matched
+	_, _, ok = pattern.parse("test.2020-01-02.08.1.log")
+	utils.AssertFalse(ok, "test")
+	_, _, ok = pattern.parse("other.2020-01-02.1.log")
+	utils.AssertFalse(ok, "test")
+	_, _, ok = pattern.parse("test.log")
+	utils.AssertFalse(ok, "test")
+}
+
+func TestFileNamePatternPeriods(t *testing.T) {
+	now := time.Date(2020, 1, 15, 13, 27, 45, 0, time.Local)
+
+	pattern, err := newFileNamePattern("debug-%d{2006-01-02_15-04}{10m}.%i.log")
+	utils.AssertNil(err, "test")
+	start := pattern.period.getStartTime(now)
+	utils.AssertTrue(start.Equal(time.Date(2020, 1, 15, 13, 20, 0, 0, time.Local)), "test")
+	utils.AssertTrue(pattern.period.getEndTime(start).Equal(time.Date(2020, 1, 15, 13, 30, 0, 0, time.Local)), "test")
+	utils.AssertTrue(pattern.period.getCronSpec() == "*/10 * * * *", "test")
+	utils.AssertTrue(pattern.format(start, 1) == "debug-2020-01-15_13-20.1.log", "test")
+
+	pattern, err = newFileNamePattern("app-%d{2006-01-02_15-04}.%i.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.unit == periodMinute && pattern.period.count == 1, "test")
+
+	pattern, err = newFileNamePattern("app.%d{2006-01-02_15}{6h}.%i.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.getStartTime(now).Equal(time.Date(2020, 1, 15, 12, 0, 0, 0, time.Local)), "test")
+
+	// 2020-01-15 is wednesday
+	pattern, err = newFileNamePattern("app.%d{week}.%i.log")
+	utils.AssertNotNil(err, "test")
+	pattern, err = newFileNamePattern("app.%d{2006-01-02}{week}.%i.log")
+	utils.AssertNil(err, "test")
+	start = pattern.period.getStartTime(now)
+	utils.AssertTrue(start.Equal(time.Date(2020, 1, 13, 0, 0, 0, 0, time.Local)), "test")
+	utils.AssertTrue(pattern.period.getEndTime(start).Equal(time.Date(2020, 1, 20, 0, 0, 0, 0, time.Local)), "test")
+	utils.AssertTrue(pattern.period.getCronSpec() == "0 0 * * 1", "test")
+
+	pattern, err = newFileNamePattern("audit-%i-%d{2006-01}.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.unit == periodMonth, "test")
+	start = pattern.period.getStartTime(now)
+	utils.AssertTrue(pattern.format(start, 2) == "audit-2-2020-01.log", "test")
+	utils.AssertTrue(pattern.period.getEndTime(start).Equal(time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local)), "test")
+	parsed, index, ok := pattern.parse("audit-2-2020-01.log.gz")
+	utils.AssertTrue(ok, "test")
+	utils.AssertTrue(parsed.Equal(start), "test")
+	utils.AssertTrue(index == 2, "test")
+
+	// `%d` alone rolls daily
+	pattern, err = newFileNamePattern("app.%d.%i.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(pattern.period.unit == periodDay, "test")
+}
+
+func TestInvalidFileNamePatterns(t *testing.T) {
+	for _, pattern := range []string{
+		"app.log",
+		"app.%d.log",
+		"app.%i.log",
+		"app.%d.%d.%i.log",
+		"app.%d.%i.%i.log",
+		"app.%d{2006-01-02.%i.log",
+		"logs/app.%d.%i.log",
+		"app.%d.%i.log.gz",
+		// year only
+		"app.%d{2006}.%i.log",
+		// period finer than layout
+		"app.%d{2006-01-02}{hour}.%i.log",
+		"app.%d{2006-01}{week}.%i.log",
+		// periods do not divide an hour or a day
+		"app.%d{2006-01-02_15-04}{7m}.%i.log",
+		"app.%d{2006-01-02_15}{5h}.%i.log",
+		"app.%d{2006-01-02_15-04}{90s}.%i.log",
+		"app.%d{2006-01-02}{fortnight}.%i.log",
+		// time without day can not be ordered
+		"app.%d{15-04}.%i.log",
+	} {
+		_, err := newFileNamePattern(pattern)
+		utils.AssertNotNil(err, pattern)
+	}
+}
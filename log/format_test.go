@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"github.com/liuyehcf/common-gtools/utils"
 	"testing"
 )
@@ -68,3 +69,19 @@ func TestContainSpace(t *testing.T) {
 func TestChinese(t *testing.T) {
 	utils.AssertTrue("你好呀，小明" == format("你好呀，{}", "小明"), "test")
 }
+
+func TestSplitThrowable(t *testing.T) {
+	err := errors.New("error")
+
+	values, throwable := splitThrowable("{}", []interface{}{err})
+	utils.AssertTrue(len(values) == 1 && throwable == nil, "test")
+
+	values, throwable = splitThrowable("{}", []interface{}{"a", err})
+	utils.AssertTrue(len(values) == 1 && throwable == err, "test")
+
+	values, throwable = splitThrowable("\\{}", []interface{}{err})
+	utils.AssertTrue(len(values) == 0 && throwable == err, "test")
+
+	values, throwable = splitThrowable("{}", []interface{}{err, "a"})
+	utils.AssertTrue(len(values) == 2 && throwable == nil, "test")
+}
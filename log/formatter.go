@@ -72,6 +72,54 @@ func format(pattern string, values ...interface{}) string {
 	return buffer.String()
 }
 
+// a trailing error which is not consumed by placeholder is taken as throwable, like slf4j
+// the original values is returned if there is no throwable
+func splitThrowable(pattern string, values []interface{}) ([]interface{}, error) {
+	valueLen := len(values)
+	if valueLen == 0 {
+		return values, nil
+	}
+
+	err, ok := values[valueLen-1].(error)
+	if !ok || countPlaceholders(pattern) >= valueLen {
+		return values, nil
+	}
+
+	return values[:valueLen-1], err
+}
+
+// count placeholders in the same way as format
+func countPlaceholders(pattern string) int {
+	count := 0
+
+	isPreEscapeChar := false
+	isPrePlaceHolderStart := false
+
+	for _, c := range pattern {
+		isCurEscapeChar := false
+		isCurPlaceHolderStart := false
+
+		if c == placeHolderStart {
+			if !isPreEscapeChar {
+				isCurPlaceHolderStart = true
+			}
+		} else if c == placeHolderStop {
+			if isPrePlaceHolderStart {
+				count += 1
+			}
+		} else if c == escapeChar {
+			if !isPreEscapeChar {
+				isCurEscapeChar = true
+			}
+		}
+
+		isPreEscapeChar = isCurEscapeChar
+		isPrePlaceHolderStart = isCurPlaceHolderStart
+	}
+
+	return count
+}
+
 func stringify(value interface{}) string {
 	return fmt.Sprintf("%v", value)
 }
@@ -0,0 +1,173 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	hexDigits = "0123456789abcdef"
+)
+
+// json lines encoder, each logging event is encoded as one json object followed by a newline
+//
+//	{"timestamp":"2006-01-02T15:04:05.999999999+08:00","level":"INFO","logger":"main","file":"/src/main.go","line":34,
+//	"message":"hello world","template":"hello {}","arguments":["world"]}
+type jsonEncoder struct {
+}
+
+func newJsonEncoder() *jsonEncoder {
+	return &jsonEncoder{}
+}
+
+func (encoder *jsonEncoder) encode(event *LoggingEvent) []byte {
+	buffer := bytes.Buffer{}
+
+	buffer.WriteString(`{"timestamp":`)
+	writeJsonString(&buffer, event.Timestamp.Format(time.RFC3339Nano))
+	buffer.WriteString(`,"level":`)
+	writeJsonString(&buffer, getLevelName(event.Level))
+	buffer.WriteString(`,"logger":`)
+	writeJsonString(&buffer, event.Name)
+	buffer.WriteString(`,"file":`)
+	writeJsonString(&buffer, event.File)
+	buffer.WriteString(`,"line":`)
+	buffer.WriteString(strconv.Itoa(event.Line))
+	buffer.WriteString(`,"message":`)
+	writeJsonString(&buffer, event.GetFormattedMessage())
+	buffer.WriteString(`,"template":`)
+	writeJsonString(&buffer, event.Message)
+
+	if len(event.Values) > 0 {
+		buffer.WriteString(`,"arguments":[`)
+		for i, value := range event.Values {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJsonValue(&buffer, value)
+		}
+		buffer.WriteByte(']')
+	}
+
+	if len(event.Mdc) > 0 {
+		buffer.WriteString(`,"mdc":`)
+		writeJsonStringMap(&buffer, event.Mdc)
+	}
+
+	if len(event.Fields) > 0 {
+		buffer.WriteString(`,"fields":`)
+		writeJsonFields(&buffer, event.Fields)
+	}
+
+	if len(event.Context) > 0 {
+		buffer.WriteString(`,"context":`)
+		writeJsonFields(&buffer, event.Context)
+	}
+
+	if event.Throwable != nil {
+		buffer.WriteString(`,"throwable":`)
+		writeJsonString(&buffer, formatThrowable(event.Throwable))
+	}
+
+	buffer.WriteString("}\n")
+
+	return buffer.Bytes()
+}
+
+// values which cannot be marshaled, such as channels and functions, are written as strings
+func writeJsonValue(buffer *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		writeJsonString(buffer, err.Error())
+		return
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		writeJsonString(buffer, stringify(value))
+		return
+	}
+	buffer.Write(content)
+}
+
+func writeJsonFields(buffer *bytes.Buffer, fields []Field) {
+	buffer.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		writeJsonString(buffer, field.Key)
+		buffer.WriteByte(':')
+		writeJsonValue(buffer, field.Value)
+	}
+	buffer.WriteByte('}')
+}
+
+// keys are sorted
+func writeJsonStringMap(buffer *bytes.Buffer, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buffer.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		writeJsonString(buffer, key)
+		buffer.WriteByte(':')
+		writeJsonString(buffer, values[key])
+	}
+	buffer.WriteByte('}')
+}
+
+func writeJsonString(buffer *bytes.Buffer, value string) {
+	buffer.WriteByte('"')
+
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"', '\\':
+				buffer.WriteByte('\\')
+				buffer.WriteByte(c)
+			case '\n':
+				buffer.WriteString(`\n`)
+			case '\r':
+				buffer.WriteString(`\r`)
+			case '\t':
+				buffer.WriteString(`\t`)
+			default:
+				if c < 0x20 {
+					buffer.WriteString(`\u00`)
+					buffer.WriteByte(hexDigits[c>>4])
+					buffer.WriteByte(hexDigits[c&0xF])
+				} else {
+					buffer.WriteByte(c)
+				}
+			}
+			i += 1
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buffer.WriteString(`\ufffd`)
+		case r == '\u2028':
+			buffer.WriteString(`\u2028`)
+		case r == '\u2029':
+			buffer.WriteString(`\u2029`)
+		default:
+			buffer.WriteString(value[i : i+size])
+		}
+		i += size
+	}
+
+	buffer.WriteByte('"')
+}
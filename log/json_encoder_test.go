@@ -0,0 +1,69 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestJsonEncoder(t *testing.T) {
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	event := &LoggingEvent{
+		Name:      "json",
+		Level:     WarnLevel,
+		Timestamp: timestamp,
+		File:      "/src/main.go",
+		Line:      34,
+		Message:   "user {} failed, {}",
+		Values:    []interface{}{map[string]int{"id": 1}, errors.New("timeout")},
+	}
+
+	content := string(newJsonEncoder().encode(event))
+	utils.AssertTrue(content == `{"timestamp":"2020-01-02T03:04:05.123456789Z","level":"WARN","logger":"json",`+
+		`"file":"/src/main.go","line":34,"message":"user map[id:1] failed, timeout","template":"user {} failed, {}",`+
+		`"arguments":[{"id":1},"timeout"]}`+"\n", content)
+}
+
+func TestJsonEncoderEscape(t *testing.T) {
+	message := "quote\" backslash\\ newline\n tab\t control\x01 <html> & 中文 \u2028 invalid\xff"
+	event := &LoggingEvent{
+		Name:      "json",
+		Level:     InfoLevel,
+		Timestamp: time.Now(),
+		Message:   message,
+		Values:    []interface{}{make(chan int)},
+	}
+
+	content := newJsonEncoder().encode(event)
+	utils.AssertTrue(content[len(content)-1] == '\n', "test")
+
+	var values map[string]interface{}
+	utils.AssertNil(json.Unmarshal(content, &values), string(content))
+	utils.AssertTrue(values["template"] == "quote\" backslash\\ newline\n tab\t control\x01 <html> & 中文 \u2028 invalid\ufffd", string(content))
+	utils.AssertTrue(values["level"] == "INFO", string(content))
+
+	arguments := values["arguments"].([]interface{})
+	utils.AssertTrue(len(arguments) == 1, string(content))
+	_, ok := arguments[0].(string)
+	utils.AssertTrue(ok, string(content))
+}
+
+func TestJsonEncoderContext(t *testing.T) {
+	event := &LoggingEvent{
+		Name:      "json",
+		Level:     InfoLevel,
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		File:      "/src/main.go",
+		Line:      34,
+		Message:   "hello",
+		Mdc:       map[string]string{"requestId": "r1", "tenantId": "t1"},
+		Fields:    []Field{NewField("userId", 1), NewField("tags", []string{"a", "b"})},
+	}
+
+	content := string(newJsonEncoder().encode(event))
+	utils.AssertTrue(content == `{"timestamp":"2020-01-02T03:04:05Z","level":"INFO","logger":"json",`+
+		`"file":"/src/main.go","line":34,"message":"hello","template":"hello",`+
+		`"mdc":{"requestId":"r1","tenantId":"t1"},"fields":{"userId":1,"tags":["a","b"]}}`+"\n", content)
+}
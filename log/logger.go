@@ -1,11 +1,13 @@
 package log
 
 import (
+	"context"
+	"errors"
 	"github.com/liuyehcf/common-gtools/utils"
 	"os"
-	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +19,18 @@ const (
 	ErrorLevel = 5
 
 	Root = "ROOT"
+
+	// level of shadow logger, which means the effective level is inherited from its ancestor
+	inheritedLevel = 0
+
+	// caller depth of the user code when logging through a virtual logger, i.e. user code -> virtualLogger -> loggerImpl
+	defaultCallerSkip = 3
+
+	// layout of the default stdout appender
+	defaultLayout = "%d{2006-01-02 15:04:05.999} [%p]-[%c]-[%L] --- %m%n"
+
+	// separator of hierarchical logger name, like `com.acme.db`
+	nameSeparator = "."
 )
 
 var (
@@ -25,6 +39,9 @@ var (
 	virtualLoggers = make(map[string]*virtualLogger, 0)
 	virtualLock    = new(sync.RWMutex)
 	rootLogger     *loggerImpl
+
+	// root logger created at startup, which is restored once a configuration without root replaces the configured one
+	defaultRootLogger *loggerImpl
 )
 
 func getLogger(name string) (*loggerImpl, bool) {
@@ -42,7 +59,7 @@ func setOrReplaceLogger(name string, logger *loggerImpl) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if logger, ok := loggers[name]; ok && utils.IsNotNil(logger) {
+	if logger, ok := loggers[name]; ok && utils.IsNotNil(logger) && !logger.isShadow {
 		rootLogger.Warn("logger '{}' is replaced", name)
 	}
 
@@ -52,15 +69,100 @@ func setOrReplaceLogger(name string, logger *loggerImpl) {
 	}
 }
 
-func removeShadowLogger(name string) {
+// get nearest configured(non-shadow) ancestor of specified logger name
+// root logger will be returned if there is no configured ancestor
+func getParentLogger(name string) *loggerImpl {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return findParentLogger(name)
+}
+
+// caller must hold the lock
+func findParentLogger(name string) *loggerImpl {
+	for parentName := getParentName(name); parentName != emptyString; parentName = getParentName(parentName) {
+		if logger, ok := loggers[parentName]; ok && utils.IsNotNil(logger) && !logger.isShadow {
+			return logger
+		}
+	}
+
+	return rootLogger
+}
+
+// `com.acme.db` -> `com.acme` -> `com` -> empty string
+func getParentName(name string) string {
+	index := strings.LastIndex(name, nameSeparator)
+	if index <= 0 {
+		return emptyString
+	}
+	return name[:index]
+}
+
+// reset parent field of all non-root loggers to their nearest configured ancestor
+func rebuildHierarchy() {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if logger, ok := loggers[name]; ok && utils.IsNotNil(logger) && logger.isShadow {
-		loggers[name] = nil
+	resetParents()
+}
+
+// caller must hold the lock
+func resetParents() {
+	for key, value := range loggers {
+		if utils.IsNotNil(value) && !isRoot(key) {
+			value.setParent(findParentLogger(key))
+		}
 	}
 }
 
+// replace the configured loggers at once, configured loggers not specified are removed,
+// and loggers created by NewLogger are kept unless specified
+// root logger is kept if not specified, unless it is configured, since its appenders are destroyed with the configuration,
+// then the default root logger is restored
+func replaceLoggers(newLoggers []*loggerImpl) {
+	lock.Lock()
+
+	previousLoggers := loggers
+	loggers = make(map[string]*loggerImpl, len(previousLoggers)+len(newLoggers)+1)
+	for key, value := range previousLoggers {
+		if utils.IsNotNil(value) && !value.isShadow && !value.isConfigured && !isRoot(key) {
+			loggers[key] = value
+		}
+	}
+
+	root := rootLogger
+	if root.isConfigured {
+		root = defaultRootLogger
+	}
+	for _, logger := range newLoggers {
+		if isRoot(logger.name) {
+			root = logger
+		} else if previous, ok := loggers[logger.name]; ok {
+			rootLogger.Warn("logger '{}' is replaced", previous.name)
+		}
+		loggers[logger.name] = logger
+	}
+	loggers[Root] = root
+	rootLogger = root
+
+	resetParents()
+
+	lock.Unlock()
+
+	// clean bind status between virtual logger and target logger
+	// this bind status will be rebuild later automatically
+	foreachVirtualLogger(func(key string, value *virtualLogger) {
+		value.unbind()
+	})
+}
+
+func getRootLogger() *loggerImpl {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return rootLogger
+}
+
 func foreachLogger(f func(key string, value *loggerImpl)) {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -147,6 +249,70 @@ type Logger interface {
 
 	// error log
 	Error(format string, values ...interface{})
+
+	// get effective level
+	Level() int
+
+	// get a child logger which attaches the fields to every logging event
+	// keyValues are alternating keys and values, like `With("userId", userId, "tenantId", tenantId)`, or Field values
+	With(keyValues ...interface{}) Logger
+
+	// get a child logger which attaches the values extracted from ctx to every logging event
+	// such as trace id, span id and mdc carried by ctx
+	Ctx(ctx context.Context) Logger
+}
+
+// change level of specified logger at runtime, the appenders and bound loggers are kept
+// loggers without configured level, i.e. descendants of this logger, will follow the new level
+func SetLevel(name string, level int) error {
+	if level < TraceLevel || level > ErrorLevel {
+		return errors.New("unsupported log level")
+	}
+
+	if isRoot(name) {
+		name = Root
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger, ok := loggers[name]
+	if ok && utils.IsNotNil(logger) {
+		atomic.StoreInt32(&logger.level, int32(level))
+		if !logger.isShadow {
+			return nil
+		}
+
+		// shadow logger becomes a configured logger, so that its descendants follow it
+		logger.isShadow = false
+	} else {
+		logger = &loggerImpl{
+			name:       name,
+			level:      int32(level),
+			additivity: true,
+			appenders:  nil,
+			isShadow:   false,
+		}
+		logger.setParent(findParentLogger(name))
+		loggers[name] = logger
+	}
+
+	resetParents()
+
+	return nil
+}
+
+// get effective level of specified logger
+func GetLevel(name string) int {
+	if isRoot(name) {
+		name = Root
+	}
+
+	if logger, ok := getLogger(name); ok {
+		return logger.Level()
+	}
+
+	return getParentLogger(name).Level()
 }
 
 func GetLogger(name string) Logger {
@@ -157,8 +323,7 @@ func GetLogger(name string) Logger {
 	}
 
 	setVirtualLoggerIfNotExist(name, &virtualLogger{
-		name:   name,
-		target: nil,
+		name: name,
 	})
 
 	logger, _ = getVirtualLogger(name)
@@ -166,24 +331,47 @@ func GetLogger(name string) Logger {
 	return logger
 }
 
-func getTargetLogger(name string) Logger {
+func getTargetLogger(name string) *loggerImpl {
+	// names like `root` refer to the root logger, instead of a shadow logger replacing it
+	if isRoot(name) {
+		name = Root
+	}
+
 	logger, ok := getLogger(name)
 
 	if ok {
 		return logger
 	}
 
-	// create shadow logger
-	return newLoggerImpl(name, rootLogger.level, true, nil, true)
+	// create shadow logger, which inherits level and appenders from its nearest configured ancestor
+	return newLoggerImpl(name, inheritedLevel, true, nil, true)
+}
+
+// get the logger of specified name without creating shadow logger, or its nearest configured ancestor otherwise,
+// which appends events the same as the shadow logger
+func findTargetLogger(name string) *loggerImpl {
+	if isRoot(name) {
+		name = Root
+	}
+
+	if logger, ok := getLogger(name); ok {
+		return logger
+	}
+	return getParentLogger(name)
 }
 
 type loggerImpl struct {
 	name       string
-	level      int
+	level      int32
 	additivity bool
 	appenders  []Appender
-	parent     *loggerImpl
 	isShadow   bool
+
+	// created by configuration file, and replaced at each reloading
+	isConfigured bool
+
+	// *loggerImpl, which is reset when the hierarchy is rebuilt while logging
+	parent atomic.Value
 }
 
 func NewLogger(name string, level int, additivity bool, appenders []Appender) Logger {
@@ -204,7 +392,7 @@ func newLoggerImpl(name string, level int, additivity bool, appenders []Appender
 			if utils.IsNotNil(appender) {
 				actualAppenders = append(actualAppenders, appender)
 			} else {
-				rootLogger.Warn("logger '{}' contains nil appender", name)
+				getRootLogger().Warn("logger '{}' contains nil appender", name)
 			}
 		}
 	} else {
@@ -215,48 +403,36 @@ func newLoggerImpl(name string, level int, additivity bool, appenders []Appender
 		name = Root
 		logger = &loggerImpl{
 			name:       name,
-			level:      level,
+			level:      int32(level),
 			additivity: false,
 			appenders:  actualAppenders,
-			parent:     nil,
 			isShadow:   false,
 		}
 		setOrReplaceLogger(name, logger)
 
-		shadowNames := make([]string, 0)
-
-		// reset all non-root loggers' parent field
-		foreachLogger(func(key string, value *loggerImpl) {
-			if !isRoot(key) {
-				value.parent = rootLogger
-
-				if value.isShadow {
-					shadowNames = append(shadowNames, key)
-				}
-			}
-		})
-
-		// remove all shadow logger, then it will rebuild later with true level of new root logger
-		for _, name := range shadowNames {
-			removeShadowLogger(name)
-		}
+		rebuildHierarchy()
 	} else {
 		logger = &loggerImpl{
 			name:       name,
-			level:      level,
+			level:      int32(level),
 			additivity: additivity,
 			appenders:  actualAppenders,
-			parent:     rootLogger,
 			isShadow:   isShadow,
 		}
+		logger.setParent(getParentLogger(name))
 
 		setOrReplaceLogger(name, logger)
+
+		// descendants of this logger may have a new nearest configured ancestor
+		if !isShadow {
+			rebuildHierarchy()
+		}
 	}
 
 	// clean bind status between virtual logger and target logger
 	// this bind status will be rebuild later automatically
 	foreachVirtualLogger(func(key string, value *virtualLogger) {
-		value.target = nil
+		value.unbind()
 	})
 
 	return logger
@@ -266,73 +442,133 @@ func isRoot(name string) bool {
 	return strings.ToUpper(name) == Root
 }
 
+func (logger *loggerImpl) getParent() *loggerImpl {
+	parent, _ := logger.parent.Load().(*loggerImpl)
+	return parent
+}
+
+func (logger *loggerImpl) setParent(parent *loggerImpl) {
+	logger.parent.Store(parent)
+}
+
 func (logger *loggerImpl) Name() string {
 	return logger.name
 }
 
+func (logger *loggerImpl) Level() int {
+	l := logger
+	level := atomic.LoadInt32(&l.level)
+
+	// shadow logger follows its nearest configured ancestor
+	for level == inheritedLevel && utils.IsNotNil(l.getParent()) {
+		l = l.getParent()
+		level = atomic.LoadInt32(&l.level)
+	}
+
+	return int(level)
+}
+
 func (logger *loggerImpl) IsTraceEnabled() bool {
-	return logger.level <= TraceLevel
+	return logger.Level() <= TraceLevel
 }
 
 func (logger *loggerImpl) Trace(format string, values ...interface{}) {
 	if logger.IsTraceEnabled() {
-		logger.callAllAppenders(TraceLevel, format, values...)
+		logger.callAllAppenders(defaultCallerSkip, TraceLevel, nil, nil, format, values...)
 	}
 }
 
 func (logger *loggerImpl) IsDebugEnabled() bool {
-	return logger.level <= DebugLevel
+	return logger.Level() <= DebugLevel
 }
 
 func (logger *loggerImpl) Debug(format string, values ...interface{}) {
 	if logger.IsDebugEnabled() {
-		logger.callAllAppenders(DebugLevel, format, values...)
+		logger.callAllAppenders(defaultCallerSkip, DebugLevel, nil, nil, format, values...)
 	}
 }
 
 func (logger *loggerImpl) IsInfoEnabled() bool {
-	return logger.level <= InfoLevel
+	return logger.Level() <= InfoLevel
 }
 
 func (logger *loggerImpl) Info(format string, values ...interface{}) {
 	if logger.IsInfoEnabled() {
-		logger.callAllAppenders(InfoLevel, format, values...)
+		logger.callAllAppenders(defaultCallerSkip, InfoLevel, nil, nil, format, values...)
 	}
 }
 
 func (logger *loggerImpl) IsWarnEnabled() bool {
-	return logger.level <= WarnLevel
+	return logger.Level() <= WarnLevel
 }
 
 func (logger *loggerImpl) Warn(format string, values ...interface{}) {
 	if logger.IsWarnEnabled() {
-		logger.callAllAppenders(WarnLevel, format, values...)
+		logger.callAllAppenders(defaultCallerSkip, WarnLevel, nil, nil, format, values...)
 	}
 }
 
 func (logger *loggerImpl) IsErrorEnabled() bool {
-	return logger.level <= ErrorLevel
+	return logger.Level() <= ErrorLevel
 }
 
 func (logger *loggerImpl) Error(format string, values ...interface{}) {
 	if logger.IsErrorEnabled() {
-		logger.callAllAppenders(ErrorLevel, format, values...)
+		logger.callAllAppenders(defaultCallerSkip, ErrorLevel, nil, nil, format, values...)
 	}
 }
 
-func (logger *loggerImpl) callAllAppenders(level int, format string, values ...interface{}) {
-	_, file, line, _ := runtime.Caller(3)
+func (logger *loggerImpl) With(keyValues ...interface{}) Logger {
+	return newChildLogger(logger, nil, newFields(keyValues))
+}
+
+func (logger *loggerImpl) Ctx(ctx context.Context) Logger {
+	return newChildLogger(logger, ctx, nil)
+}
+
+func (logger *loggerImpl) getTarget() *loggerImpl {
+	return logger
+}
+
+// skip is the caller depth of the user code
+func (logger *loggerImpl) callAllAppenders(skip int, level int, ctx context.Context, fields []Field, format string, values ...interface{}) {
+	if isShutdownCalled() {
+		return
+	}
+
+	frame := getCallerFrame(skip)
+
+	// fields passed along with the values are not used by placeholders
+	values, callFields := splitFields(values)
+	values, throwable := splitThrowable(format, values)
 	event := &LoggingEvent{
-		Name:      logger.name,
-		Level:     level,
-		Timestamp: time.Now(),
-		File:      file,
-		Line:      line,
-		Message:   format,
-		Values:    values,
+		Name:        logger.name,
+		Level:       level,
+		Timestamp:   time.Now(),
+		File:        frame.File,
+		Line:        frame.Line,
+		Function:    frame.Function,
+		GoroutineId: getGoroutineIdIfRequired(),
+		Message:     format,
+		Values:      values,
+		Mdc:         getMdc(),
+		Fields:      mergeFields(fields, callFields),
+		Throwable:   throwable,
+	}
+
+	if ctx != nil {
+		event.Context = extractContext(ctx)
+		if mdc := MdcFromContext(ctx); len(mdc) > 0 {
+			event.Mdc = mergeMdc(event.Mdc, mdc)
+		}
 	}
 
-	for l := logger; utils.IsNotNil(l); l = l.parent {
+	logger.callAppenders(event)
+}
+
+// append event to the appenders of this logger and its ancestors until additivity is false
+func (logger *loggerImpl) callAppenders(event *LoggingEvent) {
+	for l := logger; utils.IsNotNil(l); l = l.getParent() {
 		l.appendLoopOnAppenders(event)
 		if !l.additivity {
 			break
@@ -354,8 +590,10 @@ func (logger *loggerImpl) appendLoopOnAppenders(event *LoggingEvent) {
 // user may get logger before target logger created
 // virtual logger will guarantee target logger will be bound at the right time
 type virtualLogger struct {
-	name   string
-	target Logger
+	name string
+
+	// *loggerImpl, which is unbound when the target logger is created or replaced
+	target atomic.Value
 }
 
 func (logger *virtualLogger) Name() string {
@@ -363,10 +601,8 @@ func (logger *virtualLogger) Name() string {
 }
 
 func (logger *virtualLogger) IsTraceEnabled() bool {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return false
 	}
@@ -374,10 +610,8 @@ func (logger *virtualLogger) IsTraceEnabled() bool {
 }
 
 func (logger *virtualLogger) Trace(format string, values ...interface{}) {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return
 	}
@@ -385,10 +619,8 @@ func (logger *virtualLogger) Trace(format string, values ...interface{}) {
 }
 
 func (logger *virtualLogger) IsDebugEnabled() bool {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return false
 	}
@@ -396,10 +628,8 @@ func (logger *virtualLogger) IsDebugEnabled() bool {
 }
 
 func (logger *virtualLogger) Debug(format string, values ...interface{}) {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return
 	}
@@ -407,10 +637,8 @@ func (logger *virtualLogger) Debug(format string, values ...interface{}) {
 }
 
 func (logger *virtualLogger) IsInfoEnabled() bool {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return false
 	}
@@ -418,10 +646,8 @@ func (logger *virtualLogger) IsInfoEnabled() bool {
 }
 
 func (logger *virtualLogger) Info(format string, values ...interface{}) {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return
 	}
@@ -429,10 +655,8 @@ func (logger *virtualLogger) Info(format string, values ...interface{}) {
 }
 
 func (logger *virtualLogger) IsWarnEnabled() bool {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return false
 	}
@@ -440,10 +664,8 @@ func (logger *virtualLogger) IsWarnEnabled() bool {
 }
 
 func (logger *virtualLogger) Warn(format string, values ...interface{}) {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return
 	}
@@ -451,10 +673,8 @@ func (logger *virtualLogger) Warn(format string, values ...interface{}) {
 }
 
 func (logger *virtualLogger) IsErrorEnabled() bool {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return false
 	}
@@ -462,34 +682,60 @@ func (logger *virtualLogger) IsErrorEnabled() bool {
 }
 
 func (logger *virtualLogger) Error(format string, values ...interface{}) {
-	logger.buildBoundStatusIfNecessary()
-
 	// target may be null if target logger is created or replaced
-	target := logger.target
+	target := logger.getTarget()
 	if target == nil {
 		return
 	}
 	target.Error(format, values...)
 }
 
-func (logger *virtualLogger) buildBoundStatusIfNecessary() {
-	if utils.IsNotNil(logger.target) {
-		return
+func (logger *virtualLogger) Level() int {
+	// target may be null if target logger is created or replaced
+	target := logger.getTarget()
+	if target == nil {
+		return GetLevel(logger.name)
+	}
+	return target.Level()
+}
+
+func (logger *virtualLogger) With(keyValues ...interface{}) Logger {
+	return newChildLogger(logger, nil, newFields(keyValues))
+}
+
+func (logger *virtualLogger) Ctx(ctx context.Context) Logger {
+	return newChildLogger(logger, ctx, nil)
+}
+
+// get bound target logger, return nil if target logger is created or replaced
+func (logger *virtualLogger) getTarget() *loggerImpl {
+	return logger.buildBoundStatusIfNecessary()
+}
+
+func (logger *virtualLogger) buildBoundStatusIfNecessary() *loggerImpl {
+	if target, _ := logger.target.Load().(*loggerImpl); utils.IsNotNil(target) {
+		return target
 	}
 
-	logger.target = getTargetLogger(logger.name)
-	return
+	target := getTargetLogger(logger.name)
+	logger.target.Store(target)
+	return target
+}
+
+func (logger *virtualLogger) unbind() {
+	logger.target.Store((*loggerImpl)(nil))
 }
 
 func init() {
 	initConversion()
 
 	stdoutAppender, _ := NewWriterAppender(&AppenderConfig{
-		Layout:    "%d{2006-01-02 15:04:05.999} [%p]-[%c]-[%L] --- %m%n",
+		Layout:    defaultLayout,
 		Filters:   nil,
 		Writer:    os.Stdout,
 		NeedClose: false,
 	})
 
 	rootLogger = newLoggerImpl(Root, InfoLevel, false, []Appender{stdoutAppender}, false)
+	defaultRootLogger = rootLogger
 }
@@ -1,18 +1,35 @@
 package log
 
 import (
+	"fmt"
 	"time"
 )
 
+var (
+	levelNames = map[int]string{
+		TraceLevel: "TRACE",
+		DebugLevel: "DEBUG",
+		InfoLevel:  "INFO",
+		WarnLevel:  "WARN",
+		ErrorLevel: "ERROR",
+	}
+)
+
 type LoggingEvent struct {
 	Name             string
 	Level            int
 	Timestamp        time.Time
 	File             string
 	Line             int
+	Function         string
+	GoroutineId      int64
 	Message          string
 	FormattedMessage string
 	Values           []interface{}
+	Mdc              map[string]string
+	Fields           []Field
+	Context          []Field
+	Throwable        error
 	isInit           bool
 }
 
@@ -24,3 +41,11 @@ func (event *LoggingEvent) GetFormattedMessage() string {
 
 	return event.FormattedMessage
 }
+
+func getLevelName(level int) string {
+	name, ok := levelNames[level]
+	if !ok {
+		panic(fmt.Sprintf("unsupported log level '%d'", level))
+	}
+	return name
+}
@@ -0,0 +1,202 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+type mdcContextKey struct{}
+
+var (
+	// mapped diagnostic context of each goroutine, keyed by goroutine id
+	// each map is never modified after it is stored, so that logging event can refer to it without copying
+	mdcMaps = make(map[int64]map[string]string, 0)
+	mdcLock = new(sync.RWMutex)
+
+	goroutinePrefix = []byte("goroutine ")
+)
+
+// put a diagnostic value into mdc of current goroutine, which will be attached to every logging event of this goroutine
+// MdcRemove or MdcClear must be called when the value is no longer needed, otherwise it will be kept forever
+func MdcPut(key string, value string) {
+	id := getGoroutineId()
+
+	mdcLock.Lock()
+	defer mdcLock.Unlock()
+
+	mdc := copyMdc(mdcMaps[id], 1)
+	mdc[key] = value
+	mdcMaps[id] = mdc
+}
+
+// get a diagnostic value from mdc of current goroutine
+func MdcGet(key string) string {
+	return getMdc()[key]
+}
+
+// remove a diagnostic value from mdc of current goroutine
+func MdcRemove(key string) {
+	id := getGoroutineId()
+
+	mdcLock.Lock()
+	defer mdcLock.Unlock()
+
+	mdc, ok := mdcMaps[id]
+	if !ok {
+		return
+	}
+	if _, ok = mdc[key]; !ok {
+		return
+	}
+
+	if len(mdc) == 1 {
+		delete(mdcMaps, id)
+		return
+	}
+
+	mdc = copyMdc(mdc, 0)
+	delete(mdc, key)
+	mdcMaps[id] = mdc
+}
+
+// remove all the diagnostic values from mdc of current goroutine
+func MdcClear() {
+	id := getGoroutineId()
+
+	mdcLock.Lock()
+	defer mdcLock.Unlock()
+
+	delete(mdcMaps, id)
+}
+
+// get a copy of mdc of current goroutine
+func MdcCopy() map[string]string {
+	return copyMdc(getMdc(), 0)
+}
+
+// return a copy of ctx carrying the diagnostic value, which is propagated along with ctx instead of goroutine
+func ContextWithMdc(ctx context.Context, key string, value string) context.Context {
+	mdc := copyMdc(MdcFromContext(ctx), 1)
+	mdc[key] = value
+	return context.WithValue(ctx, mdcContextKey{}, mdc)
+}
+
+// get the diagnostic values carried by ctx
+func MdcFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	mdc, _ := ctx.Value(mdcContextKey{}).(map[string]string)
+	return mdc
+}
+
+// put the diagnostic values carried by ctx into mdc of current goroutine
+// the returned function restores the previous mdc, and is usually deferred
+//
+//	defer log.MdcBind(ctx)()
+func MdcBind(ctx context.Context) func() {
+	id := getGoroutineId()
+
+	mdcLock.Lock()
+	defer mdcLock.Unlock()
+
+	previous, hasPrevious := mdcMaps[id]
+	mdc := copyMdc(previous, len(MdcFromContext(ctx)))
+	for key, value := range MdcFromContext(ctx) {
+		mdc[key] = value
+	}
+	if len(mdc) > 0 {
+		mdcMaps[id] = mdc
+	}
+
+	return func() {
+		mdcLock.Lock()
+		defer mdcLock.Unlock()
+
+		if hasPrevious {
+			mdcMaps[id] = previous
+		} else {
+			delete(mdcMaps, id)
+		}
+	}
+}
+
+// get mdc of current goroutine, which must not be modified
+func getMdc() map[string]string {
+	mdcLock.RLock()
+	isEmpty := len(mdcMaps) == 0
+	mdcLock.RUnlock()
+
+	// avoid looking up goroutine id if no one uses mdc
+	if isEmpty {
+		return nil
+	}
+
+	id := getGoroutineId()
+
+	mdcLock.RLock()
+	defer mdcLock.RUnlock()
+
+	return mdcMaps[id]
+}
+
+// values of otherMdc take precedence
+func mergeMdc(mdc map[string]string, otherMdc map[string]string) map[string]string {
+	if len(mdc) == 0 {
+		return otherMdc
+	}
+
+	merged := copyMdc(mdc, len(otherMdc))
+	for key, value := range otherMdc {
+		merged[key] = value
+	}
+	return merged
+}
+
+func copyMdc(mdc map[string]string, extraSize int) map[string]string {
+	copied := make(map[string]string, len(mdc)+extraSize)
+	for key, value := range mdc {
+		copied[key] = value
+	}
+	return copied
+}
+
+// format like `key1=value1, key2=value2`, keys are sorted
+func formatMdc(mdc map[string]string) string {
+	keys := make([]string, 0, len(mdc))
+	for key := range mdc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buffer := bytes.Buffer{}
+	for i, key := range keys {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(key)
+		buffer.WriteByte('=')
+		buffer.WriteString(mdc[key])
+	}
+	return buffer.String()
+}
+
+// parse goroutine id from the first line of stack, like `goroutine 18 [running]:`
+func getGoroutineId() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, goroutinePrefix)
+	if index := bytes.IndexByte(buf, ' '); index > 0 {
+		buf = buf[:index]
+	}
+
+	id, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return id
+}
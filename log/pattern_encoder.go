@@ -2,36 +2,38 @@ package log
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"github.com/liuyehcf/common-gtools/utils"
 	"strconv"
 )
 
 const (
-	percent       = '%'
-	strikethrough = '-'
+	// date format of `%d` without option
+	defaultDateFormat = "2006-01-02 15:04:05.000"
+
+	highlightWord = "highlight"
 )
 
+// create converter with the options of conversion, like `key` of `%X{key}`
+// options is empty if there is no option block
+type converterFactory func(options []string) (converter, error)
+
 var (
-	logger  *conversion
-	date    *conversion
-	line    *conversion
-	message *conversion
-	newline *conversion
-	level   *conversion
+	// converter factories keyed by conversion word, guarded by converterFactoryLock
+	converterFactories = make(map[string]converterFactory)
 )
 
-type conversion struct {
-	words []string
-}
-
 type patternEncoder struct {
-	layout string
-	head   converter
+	layout         string
+	head           converter
+	isColorEnabled bool
 }
 
-func newPatternEncoder(layout string) (*patternEncoder, error) {
+func newPatternEncoder(layout string, isColorEnabled bool) (*patternEncoder, error) {
 	encoder := patternEncoder{
-		layout: layout,
+		layout:         layout,
+		isColorEnabled: isColorEnabled,
 	}
 
 	err := encoder.initConverterChain()
@@ -43,9 +45,13 @@ func newPatternEncoder(layout string) (*patternEncoder, error) {
 }
 
 func (encoder *patternEncoder) encode(event *LoggingEvent) []byte {
+	return convertChain(encoder.head, event)
+}
+
+func convertChain(head converter, event *LoggingEvent) []byte {
 	buffer := bytes.Buffer{}
 
-	converter := encoder.head
+	converter := head
 
 	for ; utils.IsNotNil(converter); {
 		buffer.Write(converter.convert(event))
@@ -56,233 +62,179 @@ func (encoder *patternEncoder) encode(event *LoggingEvent) []byte {
 }
 
 func (encoder *patternEncoder) initConverterChain() error {
-	runes := []rune(encoder.layout)
-
-	index := 0
-	encoder.head = &headConverter{}
-	converter := encoder.head
-
-	runesLen := len(runes)
-
-	for ; index < runesLen; {
-		c := runes[index]
-
-		if c == percent {
-			index += 1
-
-			// parse align
-			alignType, offset := getAlignType(runes, index)
-			index += offset
-
-			// parse width
-			width, offset, err := getWidth(runes, index)
-			if err != nil {
-				return err
-			}
-			index += offset
-
-			if ok, offset := matchesConversion(runes, index, logger); ok {
-				index += offset
-				nextConverter := &loggerConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-				}
-				converter.setNext(nextConverter)
-				converter = nextConverter
-			} else if ok, offset := matchesConversion(runes, index, date); ok {
-				index += offset
-
-				c = runes[index]
-				if c != placeHolderStart {
-					panic("unsupported date format '" + encoder.layout + "'")
-				}
-
-				buffer := bytes.Buffer{}
-				index += 1
-				if index < runesLen {
-					c = runes[index]
-				}
-
-				for ; index < runesLen && c != placeHolderStop; {
-					buffer.WriteRune(c)
-
-					index += 1
-
-					if index < runesLen {
-						c = runes[index]
-					}
-				}
-
-				if c != placeHolderStop {
-					panic("unsupported date format '" + encoder.layout + "'")
-				}
-
-				nextConverter := &dateConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-					format: buffer.String(),
-				}
-
-				converter.setNext(nextConverter)
-				converter = nextConverter
-
-				index += 1
-			} else if ok, offset := matchesConversion(runes, index, line); ok {
-				index += offset
-				nextConverter := &lineConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-				}
-				converter.setNext(nextConverter)
-				converter = nextConverter
-			} else if ok, offset := matchesConversion(runes, index, message); ok {
-				index += offset
-				nextConverter := &messageConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-				}
-				converter.setNext(nextConverter)
-				converter = nextConverter
-			} else if ok, offset := matchesConversion(runes, index, newline); ok {
-				index += offset
-				nextConverter := &newlineConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-				}
-				converter.setNext(nextConverter)
-				converter = nextConverter
-			} else if ok, offset := matchesConversion(runes, index, level); ok {
-				index += offset
-				nextConverter := &levelConverter{
-					abstractConverter: abstractConverter{
-						alignType: alignType,
-						width:     width,
-					},
-				}
-				converter.setNext(nextConverter)
-				converter = nextConverter
-			} else {
-				panic("unsupported pattern '" + encoder.layout + "'")
-			}
-		} else {
-			buffer := bytes.Buffer{}
-			for ; index < runesLen && c != percent; {
-				buffer.WriteRune(c)
-
-				index += 1
-
-				if index < runesLen {
-					c = runes[index]
-				}
-			}
-
-			nextConverter := &literalConverter{
-				literal: buffer.String(),
-			}
-			converter.setNext(nextConverter)
-			converter = nextConverter
+	tokens, err := tokenize(encoder.layout)
+	if err != nil {
+		return err
+	}
+
+	parser := &patternParser{
+		layout:         encoder.layout,
+		tokens:         tokens,
+		isColorEnabled: encoder.isColorEnabled,
+	}
+	head, err := parser.parseChain(false)
+	if err != nil {
+		return err
+	}
+	encoder.head = head
+
+	// throwable is printed at the end if the layout does not mention it, like logback
+	if !parser.hasThrowable {
+		converter := head
+		for ; utils.IsNotNil(converter.getNext()); {
+			converter = converter.getNext()
 		}
+
+		throwableConverter := &throwableConverter{}
+		throwableConverter.setModifier(defaultModifier)
+		converter.setNext(throwableConverter)
 	}
 
 	return nil
 }
 
-func getAlignType(runes []rune, start int) (int, int) {
-	if start >= len(runes) {
-		return rightAlign, 0
+// composite conversion converts the nested conversions as a whole, like `%highlight(%p)`
+// word is empty for group like `%-30(%d [%t])`
+func newCompositeConverter(word string, child converter, isColorEnabled bool) (converter, error) {
+	compositeConverter := compositeConverter{
+		child:          child,
+		isColorEnabled: isColorEnabled,
 	}
 
-	if runes[start] == strikethrough {
-		return leftAlign, 1
-	} else {
-		return rightAlign, 0
+	if word == emptyString {
+		return &groupConverter{
+			compositeConverter: compositeConverter,
+		}, nil
 	}
-}
 
-func getWidth(runes []rune, start int) (int, int, error) {
-	if start >= len(runes) {
-		return unlimitedWidth, 0, nil
+	if word == highlightWord {
+		return &highlightConverter{
+			compositeConverter: compositeConverter,
+		}, nil
 	}
 
-	index := start
-
-	for ; index < len(runes); {
-		v := runes[index]
-		if v < '0' || v > '9' {
-			break
-		}
-		index += 1
+	if code, ok := colorCodes[word]; ok {
+		return &colorConverter{
+			compositeConverter: compositeConverter,
+			code:               code,
+		}, nil
 	}
 
-	if index == start {
-		return unlimitedWidth, 0, nil
-	}
+	return nil, errors.New("unknown composite conversion word '" + word + "'")
+}
 
-	width, err := strconv.Atoi(string(runes[start:index]))
-	if err != nil {
-		return -1, -1, err
+// caller must hold converterFactoryLock, except in initConversion
+func registerConverterFactory(words []string, factory converterFactory) {
+	for _, word := range words {
+		converterFactories[word] = factory
 	}
+}
+
+func getConverterFactory(word string) (converterFactory, bool) {
+	converterFactoryLock.RLock()
+	defer converterFactoryLock.RUnlock()
+
+	factory, ok := converterFactories[word]
+	return factory, ok
+}
+
+func isCompositeWord(word string) bool {
+	_, isColor := colorCodes[word]
+	return word == highlightWord || isColor
+}
 
-	return width, index - start, nil
+// get the first option, or defaultValue if there is no option
+func getFirstOption(options []string, defaultValue string) string {
+	if len(options) == 0 {
+		return defaultValue
+	}
+	return options[0]
 }
 
-func matchesConversion(runes []rune, start int, conversion *conversion) (bool, int) {
-	for _, word := range conversion.words {
-		matches, offset := matchesWord(runes, start, word)
+func newLoggerConverter(options []string) (converter, error) {
+	length := unlimitedLength
 
-		if matches {
-			return matches, offset
+	if option := getFirstOption(options, emptyString); option != emptyString {
+		var err error
+		if length, err = strconv.Atoi(option); err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid logger length '%s'", option)
 		}
 	}
 
-	return false, -1
+	return &loggerConverter{
+		length: length,
+	}, nil
 }
 
-func matchesWord(runes []rune, start int, word string) (bool, int) {
-	expectedRunes := []rune(word)
+func newDateConverter(options []string) (converter, error) {
+	return &dateConverter{
+		format: getFirstOption(options, defaultDateFormat),
+	}, nil
+}
 
-	expectedLen := len(expectedRunes)
+func newMdcConverter(options []string) (converter, error) {
+	return &mdcConverter{
+		key: getFirstOption(options, emptyString),
+	}, nil
+}
 
-	if start+expectedLen > len(runes) {
-		return false, -1
-	}
+func newThreadConverter(options []string) (converter, error) {
+	requireGoroutineId()
+	return &threadConverter{}, nil
+}
 
-	for i := 0; i < expectedLen; i += 1 {
-		if runes[start+i] != expectedRunes[i] {
-			return false, -1
-		}
+func newContextConverterFactory(key string) converterFactory {
+	return func(options []string) (converter, error) {
+		return &contextConverter{
+			key: key,
+		}, nil
 	}
-
-	return true, expectedLen
 }
 
 func initConversion() {
-	logger = &conversion{
-		words: []string{"c", "lo", "logger"},
-	}
-	date = &conversion{
-		words: []string{"d", "date"},
-	}
-	line = &conversion{
-		words: []string{"L", "line"},
-	}
-	message = &conversion{
-		words: []string{"m", "msg", "message"},
-	}
-	newline = &conversion{
-		words: []string{"n"},
-	}
-	level = &conversion{
-		words: []string{"p", "le", "level"},
-	}
+	registerConverterFactory([]string{"c", "lo", "logger"}, newLoggerConverter)
+	registerConverterFactory([]string{"d", "date"}, newDateConverter)
+	registerConverterFactory([]string{"L", "line"}, func(options []string) (converter, error) {
+		return &lineConverter{}, nil
+	})
+	registerConverterFactory([]string{"m", "msg", "message"}, func(options []string) (converter, error) {
+		return &messageConverter{}, nil
+	})
+	registerConverterFactory([]string{"n"}, func(options []string) (converter, error) {
+		return &newlineConverter{}, nil
+	})
+	registerConverterFactory([]string{"p", "le", "level"}, func(options []string) (converter, error) {
+		return &levelConverter{}, nil
+	})
+	registerConverterFactory([]string{"X", "mdc"}, newMdcConverter)
+	registerConverterFactory([]string{"fields"}, func(options []string) (converter, error) {
+		return &fieldsConverter{}, nil
+	})
+	registerConverterFactory([]string{"traceId"}, newContextConverterFactory(TraceIdKey))
+	registerConverterFactory([]string{"spanId"}, newContextConverterFactory(SpanIdKey))
+	registerConverterFactory([]string{"ex", "exception", "throwable"}, func(options []string) (converter, error) {
+		return &throwableConverter{}, nil
+	})
+	registerConverterFactory([]string{"nopex", "nopexception"}, func(options []string) (converter, error) {
+		return &noThrowableConverter{}, nil
+	})
+	registerConverterFactory([]string{"M", "method"}, func(options []string) (converter, error) {
+		return &methodConverter{}, nil
+	})
+	registerConverterFactory([]string{"C", "class"}, func(options []string) (converter, error) {
+		return &classConverter{}, nil
+	})
+	registerConverterFactory([]string{"F", "file"}, func(options []string) (converter, error) {
+		return &fileConverter{}, nil
+	})
+	registerConverterFactory([]string{"t", "thread"}, newThreadConverter)
+	registerConverterFactory([]string{"pid"}, func(options []string) (converter, error) {
+		return &pidConverter{}, nil
+	})
+	registerConverterFactory([]string{"hostname"}, func(options []string) (converter, error) {
+		return &hostnameConverter{}, nil
+	})
+	registerConverterFactory([]string{"r", "relative"}, func(options []string) (converter, error) {
+		return &relativeConverter{}, nil
+	})
 }
@@ -0,0 +1,343 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	percent        = '%'
+	strikethrough  = '-'
+	maxWidthStart  = '.'
+	compositeStart = '('
+	compositeStop  = ')'
+	backslash      = '\\'
+)
+
+const (
+	// literal text, escapes like `%%` are already resolved
+	literalToken = iota
+
+	// format modifier following percent, like `-20.30`, may be empty
+	modifierToken
+
+	// conversion word following format modifier, like `logger`, empty for composite group like `%-30(...)`
+	keywordToken
+
+	// content of option block, like `2006-01-02` of `%d{2006-01-02}`
+	optionToken
+
+	compositeStartToken
+	compositeStopToken
+)
+
+type token struct {
+	kind  int
+	value string
+
+	// column of the first rune of token in layout, starts from 1
+	column int
+}
+
+// split layout into tokens
+//
+//	%-5p [%d{15:04:05}] %%
+//
+// is split into modifier `-5`, keyword `p`, literal ` [`, modifier ``, keyword `d`, option `15:04:05` and literal `] %`
+func tokenize(layout string) ([]token, error) {
+	runes := []rune(layout)
+	runesLen := len(runes)
+
+	var tokens []token
+	literal := bytes.Buffer{}
+	literalColumn := 0
+
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, token{kind: literalToken, value: literal.String(), column: literalColumn})
+			literal.Reset()
+		}
+	}
+	appendLiteral := func(c rune, column int) {
+		if literal.Len() == 0 {
+			literalColumn = column
+		}
+		literal.WriteRune(c)
+	}
+
+	// columns of the unclosed composite conversions
+	var compositeColumns []int
+
+	index := 0
+	for ; index < runesLen; {
+		c := runes[index]
+
+		if c == percent && index+1 < runesLen && runes[index+1] == percent {
+			appendLiteral(percent, index+1)
+			index += 2
+		} else if c == backslash && index+1 < runesLen && isEscapable(runes[index+1]) {
+			appendLiteral(runes[index+1], index+1)
+			index += 2
+		} else if c == compositeStop && len(compositeColumns) > 0 {
+			flushLiteral()
+			tokens = append(tokens, token{kind: compositeStopToken, column: index + 1})
+			compositeColumns = compositeColumns[:len(compositeColumns)-1]
+			index += 1
+
+			var err error
+			if tokens, index, err = tokenizeOptions(runes, index, tokens); err != nil {
+				return nil, err
+			}
+		} else if c == percent {
+			flushLiteral()
+			conversionColumn := index + 1
+			index += 1
+
+			start := index
+			index = skipModifier(runes, index)
+			tokens = append(tokens, token{kind: modifierToken, value: string(runes[start:index]), column: start + 1})
+
+			start = index
+			for ; index < runesLen && isKeywordRune(runes[index]); {
+				index += 1
+			}
+			tokens = append(tokens, token{kind: keywordToken, value: string(runes[start:index]), column: start + 1})
+
+			if index < runesLen && runes[index] == compositeStart {
+				tokens = append(tokens, token{kind: compositeStartToken, column: index + 1})
+				compositeColumns = append(compositeColumns, conversionColumn)
+				index += 1
+				continue
+			}
+
+			if start == index {
+				return nil, newLayoutError(layout, conversionColumn, "missing conversion word after '%'")
+			}
+
+			var err error
+			if tokens, index, err = tokenizeOptions(runes, index, tokens); err != nil {
+				return nil, err
+			}
+		} else {
+			appendLiteral(c, index+1)
+			index += 1
+		}
+	}
+
+	flushLiteral()
+
+	if len(compositeColumns) > 0 {
+		return nil, newLayoutError(layout, compositeColumns[len(compositeColumns)-1], "unterminated composite conversion")
+	}
+
+	return tokens, nil
+}
+
+// tokenize option blocks like `{...}{...}` starting from index
+func tokenizeOptions(runes []rune, index int, tokens []token) ([]token, int, error) {
+	for ; index < len(runes) && runes[index] == placeHolderStart; {
+		stop := index + 1
+		for ; stop < len(runes) && runes[stop] != placeHolderStop; {
+			stop += 1
+		}
+		if stop >= len(runes) {
+			return nil, -1, newLayoutError(string(runes), index+1, "unterminated option")
+		}
+
+		tokens = append(tokens, token{kind: optionToken, value: string(runes[index+1 : stop]), column: index + 2})
+		index = stop + 1
+	}
+
+	return tokens, index, nil
+}
+
+// skip format modifier like `-20.30` or `.-30`, the modifier is validated when it is parsed
+func skipModifier(runes []rune, index int) int {
+	for ; index < len(runes); {
+		c := runes[index]
+		if c != strikethrough && c != maxWidthStart && (c < '0' || c > '9') {
+			break
+		}
+		index += 1
+	}
+	return index
+}
+
+func isKeywordRune(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// runes which can be escaped by backslash
+func isEscapable(c rune) bool {
+	return c == percent || c == compositeStart || c == compositeStop || c == backslash
+}
+
+type patternParser struct {
+	layout         string
+	tokens         []token
+	index          int
+	isColorEnabled bool
+
+	// whether throwable is printed or ignored explicitly by the layout
+	hasThrowable bool
+}
+
+// parse converters until the end of tokens, or until the end of composite conversion if it is nested
+// the returned chain starts with a head converter
+func (parser *patternParser) parseChain(isNested bool) (converter, error) {
+	head := &headConverter{}
+	var tail converter = head
+
+	for ; parser.index < len(parser.tokens); {
+		current := parser.tokens[parser.index]
+
+		var nextConverter converter
+		switch current.kind {
+		case literalToken:
+			parser.index += 1
+			nextConverter = &literalConverter{
+				literal: current.value,
+			}
+		case modifierToken:
+			var err error
+			if nextConverter, err = parser.parseConversion(); err != nil {
+				return nil, err
+			}
+		case compositeStopToken:
+			if isNested {
+				return head, nil
+			}
+			return nil, newLayoutError(parser.layout, current.column, "unexpected ')'")
+		default:
+			return nil, newLayoutError(parser.layout, current.column, "unexpected token")
+		}
+
+		if nextConverter != nil {
+			tail.setNext(nextConverter)
+			tail = nextConverter
+		}
+	}
+
+	return head, nil
+}
+
+// parse conversion starting with format modifier, nil is returned if the conversion prints nothing, like `%nopex`
+func (parser *patternParser) parseConversion() (converter, error) {
+	modifierValue := parser.next()
+	modifier, err := parseModifier(modifierValue.value)
+	if err != nil {
+		return nil, newLayoutError(parser.layout, modifierValue.column, err.Error())
+	}
+
+	keyword := parser.next()
+
+	var result converter
+	if parser.peek(compositeStartToken) {
+		parser.next()
+
+		child, err := parser.parseChain(true)
+		if err != nil {
+			return nil, err
+		}
+		parser.next()
+
+		// options of composite conversion are not used yet
+		parser.parseOptions()
+
+		if result, err = newCompositeConverter(keyword.value, child, parser.isColorEnabled); err != nil {
+			return nil, newLayoutError(parser.layout, keyword.column, err.Error())
+		}
+	} else {
+		options := parser.parseOptions()
+
+		factory, ok := getConverterFactory(keyword.value)
+		if !ok {
+			return nil, newLayoutError(parser.layout, keyword.column, "unknown conversion word '"+keyword.value+"'")
+		}
+		if result, err = factory(options); err != nil {
+			return nil, newLayoutError(parser.layout, keyword.column, err.Error())
+		}
+	}
+
+	switch result.(type) {
+	case *throwableConverter:
+		parser.hasThrowable = true
+	case *noThrowableConverter:
+		parser.hasThrowable = true
+		return nil, nil
+	}
+
+	result.setModifier(modifier)
+	return result, nil
+}
+
+func (parser *patternParser) parseOptions() []string {
+	var options []string
+	for ; parser.peek(optionToken); {
+		options = append(options, parser.next().value)
+	}
+	return options
+}
+
+func (parser *patternParser) peek(kind int) bool {
+	return parser.index < len(parser.tokens) && parser.tokens[parser.index].kind == kind
+}
+
+// tokenize guarantees the structure of tokens, so there is no need to check the kind
+func (parser *patternParser) next() token {
+	token := parser.tokens[parser.index]
+	parser.index += 1
+	return token
+}
+
+// parse format modifier like `-20.30`, `.-30` or empty string
+func parseModifier(value string) (formatModifier, error) {
+	modifier := formatModifier{
+		alignType: rightAlign,
+		width:     unlimitedWidth,
+		maxWidth:  unlimitedWidth,
+	}
+
+	if strings.HasPrefix(value, string(strikethrough)) {
+		modifier.alignType = leftAlign
+		value = value[1:]
+	}
+
+	widthValue := value
+	maxWidthValue := emptyString
+	hasMaxWidth := false
+	if index := strings.IndexRune(value, maxWidthStart); index >= 0 {
+		widthValue = value[:index]
+		maxWidthValue = value[index+1:]
+		hasMaxWidth = true
+	}
+
+	var err error
+	if widthValue != emptyString {
+		if modifier.width, err = strconv.Atoi(widthValue); err != nil || modifier.width < 0 {
+			return modifier, fmt.Errorf("invalid width '%s'", widthValue)
+		}
+	}
+
+	if hasMaxWidth {
+		if strings.HasPrefix(maxWidthValue, string(strikethrough)) {
+			modifier.isTruncateFromEnd = true
+			maxWidthValue = maxWidthValue[1:]
+		}
+		if maxWidthValue == emptyString {
+			return modifier, errors.New("missing max width")
+		}
+		if modifier.maxWidth, err = strconv.Atoi(maxWidthValue); err != nil || modifier.maxWidth < 0 {
+			return modifier, fmt.Errorf("invalid max width '%s'", maxWidthValue)
+		}
+	}
+
+	return modifier, nil
+}
+
+func newLayoutError(layout string, column int, message string) error {
+	return fmt.Errorf("invalid layout '%s': %s at column %d", layout, message, column)
+}
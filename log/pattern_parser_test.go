@@ -0,0 +1,64 @@
+package log
+
+import (
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+)
+
+func TestPatternEscape(t *testing.T) {
+	encoder, err := newPatternEncoder("100%% [%p] \\(%m\\) \\%n (%c)%n", false)
+	utils.AssertNil(err, "test")
+
+	event := &LoggingEvent{
+		Name:    "escape",
+		Level:   InfoLevel,
+		Message: "hello",
+	}
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "100% [INFO] (hello) %n (escape)\n", content)
+}
+
+func TestPatternWords(t *testing.T) {
+	encoder, err := newPatternEncoder("[%logger]-[%msg]-[%level]-[%lo]-[%le]%nopex", false)
+	utils.AssertNil(err, "test")
+
+	event := &LoggingEvent{
+		Name:    "words",
+		Level:   WarnLevel,
+		Message: "hello",
+	}
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "[words]-[hello]-[WARN]-[words]-[WARN]", content)
+}
+
+func TestPatternCompositeGroup(t *testing.T) {
+	encoder, err := newPatternEncoder("%-16(%p [%c]) %.-3(%m)%X{k}{ignored}%n", false)
+	utils.AssertNil(err, "test")
+
+	event := &LoggingEvent{
+		Name:    "group",
+		Level:   InfoLevel,
+		Message: "hello",
+		Mdc:     map[string]string{"k": "v"},
+	}
+	content := string(encoder.encode(event))
+	utils.AssertTrue(content == "INFO [group]     helv\n", content)
+}
+
+func TestPatternErrors(t *testing.T) {
+	assertLayoutError("%m %unknown%n", "invalid layout '%m %unknown%n': unknown conversion word 'unknown' at column 5")
+	assertLayoutError("[%d{2006-01-02] %m", "invalid layout '[%d{2006-01-02] %m': unterminated option at column 4")
+	assertLayoutError("%m 100%", "invalid layout '%m 100%': missing conversion word after '%' at column 7")
+	assertLayoutError("%-20(%p %m", "invalid layout '%-20(%p %m': unterminated composite conversion at column 1")
+	assertLayoutError("%.c", "invalid layout '%.c': missing max width at column 2")
+	assertLayoutError("%5-3c", "invalid layout '%5-3c': invalid width '5-3' at column 2")
+	assertLayoutError("%c{x}", "invalid layout '%c{x}': invalid logger length 'x' at column 2")
+	assertLayoutError("%purple(%m)", "invalid layout '%purple(%m)': unknown composite conversion word 'purple' at column 2")
+	assertLayoutError("中文%x", "invalid layout '中文%x': unknown conversion word 'x' at column 4")
+}
+
+func assertLayoutError(layout string, expected string) {
+	_, err := newPatternEncoder(layout, false)
+	utils.AssertNotNil(err, layout)
+	utils.AssertTrue(err.Error() == expected, err.Error())
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// set by Shutdown, logging events are ignored afterwards
+	isShutdown int32
+)
+
+// wait until the events accepted by all the appenders are written, files are synced to disk as well
+// an error is returned if some events are not written before timeout
+func Flush(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var pending int64
+	for _, appender := range getAllAppenders() {
+		if flushable, ok := appender.(flushableAppender); ok {
+			if !flushable.flush(ctx) {
+				pending += flushable.getPendingCount()
+			}
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("flush timeout, %d events are not written", pending)
+	}
+	return nil
+}
+
+// stop accepting events, and destroy all the appenders after the queued events are written,
+// which means files are synced and closed, and rolling jobs are stopped
+// events which are not written before ctx is done are lost, and reported by the returned error
+// it is usually called before the process exits
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	_ = log.Shutdown(ctx)
+func Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&isShutdown, 1)
+
+	configurationLock.Lock()
+	stopConfigurationWatcher()
+	configurationLock.Unlock()
+
+	var lost int64
+	for _, appender := range getAllAppenders() {
+		flushable, ok := appender.(flushableAppender)
+		if !ok {
+			appender.Destroy()
+			continue
+		}
+
+		flushable.flush(ctx)
+		appender.Destroy()
+		flushable.waitStopped(ctx)
+		lost += flushable.getPendingCount()
+	}
+
+	if lost > 0 {
+		return fmt.Errorf("shutdown timeout, %d events are lost", lost)
+	}
+	return nil
+}
+
+func isShutdownCalled() bool {
+	return atomic.LoadInt32(&isShutdown) == 1
+}
+
+// get the distinct appenders of all the configured loggers
+func getAllAppenders() []Appender {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	appenders := make([]Appender, 0)
+
+	collect := func(logger *loggerImpl) {
+		for _, appender := range logger.appenders {
+			if appender == nil || containsAppender(appenders, appender) {
+				continue
+			}
+			appenders = append(appenders, appender)
+		}
+	}
+
+	collect(rootLogger)
+	for _, logger := range loggers {
+		collect(logger)
+	}
+
+	return appenders
+}
+
+func containsAppender(appenders []Appender, target Appender) bool {
+	for _, appender := range appenders {
+		if isSameAppender(appender, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// comparing panics if appenders are values of the same non-comparable type, like structs containing slices,
+// which are taken as different appenders
+func isSameAppender(left Appender, right Appender) (isSame bool) {
+	defer func() {
+		if recover() != nil {
+			isSame = false
+		}
+	}()
+	return left == right
+}
@@ -0,0 +1,266 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	defaultSocketReconnectInterval = time.Second
+)
+
+type SocketConfig struct {
+	// `host:port` of the socket receiver
+	Address string
+
+	// minimum interval between two connecting attempts, 1s by default
+	ReconnectInterval time.Duration
+
+	// maximum number of events kept while disconnected, the earliest ones are dropped once exceeded,
+	// DefaultQueueSize by default
+	BacklogSize int
+}
+
+// logging event transferred between socket appender and socket receiver, one json object per line
+type socketEvent struct {
+	Name             string            `json:"name"`
+	Level            int               `json:"level"`
+	Timestamp        time.Time         `json:"timestamp"`
+	File             string            `json:"file,omitempty"`
+	Line             int               `json:"line,omitempty"`
+	Function         string            `json:"function,omitempty"`
+	GoroutineId      int64             `json:"goroutineId,omitempty"`
+	Message          string            `json:"message"`
+	FormattedMessage string            `json:"formattedMessage"`
+	Mdc              map[string]string `json:"mdc,omitempty"`
+	Fields           []socketField     `json:"fields,omitempty"`
+	Context          []socketField     `json:"context,omitempty"`
+	Throwable        string            `json:"throwable,omitempty"`
+}
+
+// values are stringified, so that they are printed the same as the local ones
+type socketField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// throwable of remote event, which can only be printed
+type remoteThrowable struct {
+	formatted string
+}
+
+func (throwable *remoteThrowable) Error() string {
+	return throwable.formatted
+}
+
+type socketAppender struct {
+	abstractAppender
+	address           string
+	reconnectInterval time.Duration
+	backlogSize       int
+	connection        *reconnectingConnection
+
+	// only accessed by event loop
+	backlog [][]byte
+}
+
+func NewSocketAppender(config *AppenderConfig) (*socketAppender, error) {
+	socketConfig := config.Socket
+	if socketConfig == nil {
+		return nil, errors.New("socket config is required for socket appender")
+	}
+	if socketConfig.Address == emptyString {
+		return nil, errors.New("address is required for socket appender")
+	}
+	if socketConfig.ReconnectInterval < 0 {
+		return nil, errors.New("ReconnectInterval must not be negative")
+	}
+	if socketConfig.BacklogSize < 0 {
+		return nil, errors.New("BacklogSize must not be negative")
+	}
+
+	abstractAppender, err := newAbstractAppender(config, &socketEncoder{})
+	if err != nil {
+		return nil, err
+	}
+
+	reconnectInterval := socketConfig.ReconnectInterval
+	if reconnectInterval == 0 {
+		reconnectInterval = defaultSocketReconnectInterval
+	}
+	backlogSize := socketConfig.BacklogSize
+	if backlogSize == 0 {
+		backlogSize = DefaultQueueSize
+	}
+
+	appender := &socketAppender{
+		abstractAppender:  abstractAppender,
+		address:           socketConfig.Address,
+		reconnectInterval: reconnectInterval,
+		backlogSize:       backlogSize,
+		backlog:           make([][]byte, 0),
+	}
+	appender.connection = newReconnectingConnection(appender.dial, reconnectInterval)
+
+	err = appender.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return appender, nil
+}
+
+func (appender *socketAppender) Start() error {
+	return appender.start(func() {
+		go appender.onEventLoop()
+	})
+}
+
+// stop accepting events, the backlog is sent once more if possible, and the connection is closed after that
+func (appender *socketAppender) Stop() {
+	appender.stop(appender.onEventLoop)
+}
+
+func (appender *socketAppender) Destroy() {
+	appender.Stop()
+}
+
+// dequeued events are kept in backlog until they are sent, so flushing waits for the backlog,
+// and fails if the receiver is unreachable until ctx is done
+func (appender *socketAppender) flush(ctx context.Context) bool {
+	return appender.waitDrained(ctx)
+}
+
+// the queue is always drained into backlog, so that callers are not blocked while disconnected
+func (appender *socketAppender) onEventLoop() {
+	defer close(appender.stopped)
+	defer appender.connection.close()
+
+	ticker := time.NewTicker(appender.reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case content, ok := <-appender.queue:
+			if !ok {
+				// the events which are still in backlog are lost
+				appender.send()
+				return
+			}
+			appender.addBacklog(content)
+			appender.send()
+		case <-ticker.C:
+			appender.send()
+		}
+	}
+}
+
+func (appender *socketAppender) addBacklog(content []byte) {
+	if len(appender.backlog) >= appender.backlogSize {
+		appender.backlog[0] = nil
+		appender.backlog = appender.backlog[1:]
+		appender.onDropped()
+	}
+	appender.backlog = append(appender.backlog, content)
+}
+
+// send the backlog in order, until it is empty or the receiver is unreachable
+func (appender *socketAppender) send() {
+	for ; len(appender.backlog) > 0; {
+		if !appender.connection.connect() {
+			return
+		}
+		if !appender.connection.write(appender.backlog[0]) {
+			continue
+		}
+
+		appender.backlog[0] = nil
+		appender.backlog = appender.backlog[1:]
+		appender.onWritten()
+	}
+}
+
+func (appender *socketAppender) dial() (net.Conn, error) {
+	return net.DialTimeout("tcp", appender.address, dialTimeout)
+}
+
+type socketEncoder struct {
+}
+
+func (encoder *socketEncoder) encode(event *LoggingEvent) []byte {
+	remote := socketEvent{
+		Name:             event.Name,
+		Level:            event.Level,
+		Timestamp:        event.Timestamp,
+		File:             event.File,
+		Line:             event.Line,
+		Function:         event.Function,
+		GoroutineId:      event.GoroutineId,
+		Message:          event.Message,
+		FormattedMessage: event.GetFormattedMessage(),
+		Mdc:              event.Mdc,
+		Fields:           newSocketFields(event.Fields),
+		Context:          newSocketFields(event.Context),
+	}
+	if event.Throwable != nil {
+		remote.Throwable = formatThrowable(event.Throwable)
+	}
+
+	bytes, err := json.Marshal(&remote)
+	if err != nil {
+		return nil
+	}
+	return append(bytes, '\n')
+}
+
+func newSocketFields(fields []Field) []socketField {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	socketFields := make([]socketField, 0, len(fields))
+	for _, field := range fields {
+		socketFields = append(socketFields, socketField{
+			Key:   field.Key,
+			Value: stringify(field.Value),
+		})
+	}
+	return socketFields
+}
+
+func (remote *socketEvent) toLoggingEvent() *LoggingEvent {
+	event := &LoggingEvent{
+		Name:             remote.Name,
+		Level:            remote.Level,
+		Timestamp:        remote.Timestamp,
+		File:             remote.File,
+		Line:             remote.Line,
+		Function:         remote.Function,
+		GoroutineId:      remote.GoroutineId,
+		Message:          remote.Message,
+		FormattedMessage: remote.FormattedMessage,
+		Mdc:              remote.Mdc,
+		Fields:           toFields(remote.Fields),
+		Context:          toFields(remote.Context),
+		isInit:           true,
+	}
+	if remote.Throwable != emptyString {
+		event.Throwable = &remoteThrowable{formatted: remote.Throwable}
+	}
+	return event
+}
+
+func toFields(socketFields []socketField) []Field {
+	if len(socketFields) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, len(socketFields))
+	for _, field := range socketFields {
+		fields = append(fields, NewField(field.Key, field.Value))
+	}
+	return fields
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
+
+// server receiving events from socket appenders, and replaying them into local loggers of the same name
+// events are filtered by the level of local loggers, and appended to their appenders like local events
+type socketReceiver struct {
+	listener net.Listener
+	lock     *sync.Mutex
+	conns    map[net.Conn]bool
+	isClosed bool
+	group    *sync.WaitGroup
+}
+
+// listen on addr like `:4560`, and start accepting connections
+func NewSocketReceiver(addr string) (*socketReceiver, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver := &socketReceiver{
+		listener: listener,
+		lock:     new(sync.Mutex),
+		conns:    make(map[net.Conn]bool, 0),
+		group:    new(sync.WaitGroup),
+	}
+
+	receiver.group.Add(1)
+	go receiver.onAcceptLoop()
+
+	return receiver, nil
+}
+
+// address actually listened, which is useful if the port of addr is 0
+func (receiver *socketReceiver) Addr() net.Addr {
+	return receiver.listener.Addr()
+}
+
+// stop accepting connections, and close the connected ones
+func (receiver *socketReceiver) Close() error {
+	receiver.lock.Lock()
+	if receiver.isClosed {
+		receiver.lock.Unlock()
+		return nil
+	}
+	receiver.isClosed = true
+	err := receiver.listener.Close()
+	for conn := range receiver.conns {
+		_ = conn.Close()
+	}
+	receiver.lock.Unlock()
+
+	receiver.group.Wait()
+	return err
+}
+
+// like net/http, accepting is retried with backoff on temporary errors such as EMFILE, and stopped on the others
+func (receiver *socketReceiver) onAcceptLoop() {
+	defer receiver.group.Done()
+
+	var delay time.Duration
+	for {
+		conn, err := receiver.listener.Accept()
+		if err != nil {
+			if receiver.isClosing() {
+				return
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				if delay == 0 {
+					delay = minAcceptDelay
+				} else if delay *= 2; delay > maxAcceptDelay {
+					delay = maxAcceptDelay
+				}
+				time.Sleep(delay)
+				continue
+			}
+			return
+		}
+		delay = 0
+
+		if !receiver.addConnection(conn) {
+			_ = conn.Close()
+			return
+		}
+
+		receiver.group.Add(1)
+		go receiver.onConnectionLoop(conn)
+	}
+}
+
+// read events until the connection is closed, or the content is broken
+func (receiver *socketReceiver) onConnectionLoop(conn net.Conn) {
+	defer receiver.group.Done()
+	defer receiver.removeConnection(conn)
+
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		remote := &socketEvent{}
+		if err := decoder.Decode(remote); err != nil {
+			return
+		}
+		replayEvent(remote.toLoggingEvent())
+	}
+}
+
+func (receiver *socketReceiver) isClosing() bool {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	return receiver.isClosed
+}
+
+func (receiver *socketReceiver) addConnection(conn net.Conn) bool {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	if receiver.isClosed {
+		return false
+	}
+	receiver.conns[conn] = true
+	return true
+}
+
+func (receiver *socketReceiver) removeConnection(conn net.Conn) {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	_ = conn.Close()
+	delete(receiver.conns, conn)
+}
+
+// append remote event to the local logger of the same name, if its level is enabled
+// loggers are never created for remote names, so that remote events can neither replace root nor pile up loggers
+func replayEvent(event *LoggingEvent) {
+	if isShutdownCalled() {
+		return
+	}
+	if event.Level < TraceLevel || event.Level > ErrorLevel {
+		return
+	}
+
+	logger := findTargetLogger(event.Name)
+	if event.Level < logger.Level() {
+		return
+	}
+	logger.callAppenders(event)
+}
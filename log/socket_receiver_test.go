@@ -0,0 +1,89 @@
+package log
+
+import (
+	"errors"
+	"github.com/liuyehcf/common-gtools/utils"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type temporaryError struct {
+}
+
+func (err *temporaryError) Error() string {
+	return "too many open files"
+}
+
+func (err *temporaryError) Timeout() bool {
+	return false
+}
+
+func (err *temporaryError) Temporary() bool {
+	return true
+}
+
+// listener failing with temporary errors until it is closed
+type failingListener struct {
+	accepts int32
+	closed  chan struct{}
+}
+
+func (listener *failingListener) Accept() (net.Conn, error) {
+	atomic.AddInt32(&listener.accepts, 1)
+	select {
+	case <-listener.closed:
+		return nil, errors.New("use of closed network connection")
+	default:
+		return nil, &temporaryError{}
+	}
+}
+
+func (listener *failingListener) Close() error {
+	close(listener.closed)
+	return nil
+}
+
+func (listener *failingListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func TestAcceptBackoff(t *testing.T) {
+	listener := &failingListener{closed: make(chan struct{})}
+	receiver := &socketReceiver{
+		listener: listener,
+		lock:     new(sync.Mutex),
+		conns:    make(map[net.Conn]bool, 0),
+		group:    new(sync.WaitGroup),
+	}
+	receiver.group.Add(1)
+	go receiver.onAcceptLoop()
+
+	time.Sleep(time.Millisecond * 100)
+	utils.AssertNil(receiver.Close(), "test")
+
+	// 5ms, 10ms, 20ms, 40ms, ...
+	accepts := atomic.LoadInt32(&listener.accepts)
+	utils.AssertTrue(accepts > 1 && accepts < 10, "test")
+}
+
+// events are below the level of root, so that nothing is printed
+func TestReplayEventWithoutCreatingLoggers(t *testing.T) {
+	for _, name := range []string{"remote.replay", "root", "ROOT", ""} {
+		replayEvent(&LoggingEvent{
+			Name:      name,
+			Level:     TraceLevel,
+			Timestamp: time.Now(),
+			Message:   "remote",
+			isInit:    true,
+		})
+	}
+
+	_, ok := getLogger("remote.replay")
+	utils.AssertFalse(ok, "test")
+	_, ok = getLogger("root")
+	utils.AssertFalse(ok, "test")
+	utils.AssertTrue(getRootLogger() == defaultRootLogger, "test")
+}
@@ -2,10 +2,13 @@ package log
 
 import (
 	"github.com/liuyehcf/common-gtools/buffer"
+	"sync"
 )
 
+// the buffer is written by the event loop of appender and read by others, so it is guarded by lock
 type StringWriter struct {
-	buf buffer.ByteBuffer
+	buf  buffer.ByteBuffer
+	lock sync.Mutex
 }
 
 func NewStringWriter(buf buffer.ByteBuffer) *StringWriter {
@@ -13,6 +16,8 @@ func NewStringWriter(buf buffer.ByteBuffer) *StringWriter {
 }
 
 func (writer *StringWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
 	writer.buf.Write(p)
 	return len(p), nil
 }
@@ -22,6 +27,8 @@ func (writer *StringWriter) Close() error {
 }
 
 func (writer *StringWriter) ReadString() string {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
 	bytes := make([]byte, writer.buf.ReadableBytes())
 
 	writer.buf.Read(bytes)
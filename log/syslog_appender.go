@@ -0,0 +1,392 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	SyslogNetworkUdp = "udp"
+	SyslogNetworkTcp = "tcp"
+
+	// local socket like `/dev/log`, datagram is preferred and stream is tried if it is not supported
+	SyslogNetworkUnix = "unix"
+
+	// `<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG`
+	SyslogFormatRFC5424 = "rfc5424"
+
+	// `<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG`
+	SyslogFormatRFC3164 = "rfc3164"
+
+	// SD-ID of the structured data built from fields and context, 32473 is the enterprise number reserved for documentation
+	SyslogDefaultStructuredDataId = "fields@32473"
+
+	defaultSyslogFacility          = "user"
+	defaultSyslogLayout            = "%m"
+	defaultSyslogReconnectInterval = time.Second
+	syslogNilValue                 = "-"
+	rfc5424TimestampFormat         = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimestampFormat         = "Jan _2 15:04:05"
+
+	// maximum length of header fields defined by RFC 5424
+	maxHostnameLength  = 255
+	maxAppNameLength   = 48
+	maxParamNameLength = 32
+)
+
+var (
+	syslogFacilities = map[string]int{
+		"kern":     0,
+		"user":     1,
+		"mail":     2,
+		"daemon":   3,
+		"auth":     4,
+		"syslog":   5,
+		"lpr":      6,
+		"news":     7,
+		"uucp":     8,
+		"cron":     9,
+		"authpriv": 10,
+		"ftp":      11,
+		"local0":   16,
+		"local1":   17,
+		"local2":   18,
+		"local3":   19,
+		"local4":   20,
+		"local5":   21,
+		"local6":   22,
+		"local7":   23,
+	}
+
+	// syslog has no trace severity, so trace is mapped to debug as well
+	syslogSeverities = map[int]int{
+		TraceLevel: 7,
+		DebugLevel: 7,
+		InfoLevel:  6,
+		WarnLevel:  4,
+		ErrorLevel: 3,
+	}
+)
+
+type SyslogConfig struct {
+	// SyslogNetworkUdp, SyslogNetworkTcp or SyslogNetworkUnix
+	Network string
+
+	// `host:port` for udp and tcp, socket path for unix, like `/dev/log`
+	Address string
+
+	// SyslogFormatRFC5424 by default
+	Format string
+
+	// facility name like `user` or `local0`, `user` by default
+	Facility string
+
+	// APP-NAME of RFC 5424 or TAG of RFC 3164, name of the executable by default
+	AppName string
+
+	// HOSTNAME, name of the host by default
+	Hostname string
+
+	// SD-ID of structured data, SyslogDefaultStructuredDataId by default, only used for SyslogFormatRFC5424
+	StructuredDataId string
+
+	// minimum interval between two connecting attempts, 1s by default
+	ReconnectInterval time.Duration
+}
+
+type syslogAppender struct {
+	abstractAppender
+	network    string
+	address    string
+	connection *reconnectingConnection
+
+	// only accessed by event loop
+	isStream bool
+}
+
+func NewSyslogAppender(config *AppenderConfig) (*syslogAppender, error) {
+	syslogConfig := config.Syslog
+	if syslogConfig == nil {
+		return nil, errors.New("syslog config is required for syslog appender")
+	}
+
+	switch syslogConfig.Network {
+	case SyslogNetworkUdp, SyslogNetworkTcp, SyslogNetworkUnix:
+	default:
+		return nil, fmt.Errorf("unsupported syslog network '%s'", syslogConfig.Network)
+	}
+	if syslogConfig.Address == emptyString {
+		return nil, errors.New("address is required for syslog appender")
+	}
+	if syslogConfig.ReconnectInterval < 0 {
+		return nil, errors.New("ReconnectInterval must not be negative")
+	}
+
+	encoder, err := newSyslogEncoder(config)
+	if err != nil {
+		return nil, err
+	}
+	abstractAppender, err := newAbstractAppender(config, encoder)
+	if err != nil {
+		return nil, err
+	}
+
+	reconnectInterval := syslogConfig.ReconnectInterval
+	if reconnectInterval == 0 {
+		reconnectInterval = defaultSyslogReconnectInterval
+	}
+
+	appender := &syslogAppender{
+		abstractAppender: abstractAppender,
+		network:          syslogConfig.Network,
+		address:          syslogConfig.Address,
+	}
+	appender.connection = newReconnectingConnection(appender.dial, reconnectInterval)
+
+	err = appender.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return appender, nil
+}
+
+func (appender *syslogAppender) Start() error {
+	return appender.start(func() {
+		go appender.onEventLoop()
+	})
+}
+
+// stop accepting events, the queued events are still sent, and the connection is closed after that
+func (appender *syslogAppender) Stop() {
+	appender.stop(appender.onEventLoop)
+}
+
+func (appender *syslogAppender) Destroy() {
+	appender.Stop()
+}
+
+// events are sent without buffering, so there is nothing to sync
+func (appender *syslogAppender) flush(ctx context.Context) bool {
+	return appender.waitDrained(ctx)
+}
+
+func (appender *syslogAppender) onEventLoop() {
+	defer close(appender.stopped)
+	defer appender.connection.close()
+
+	for content := range appender.queue {
+		appender.write(content)
+		appender.onWritten()
+	}
+}
+
+// the message is lost if it can not be sent after reconnecting once
+func (appender *syslogAppender) write(message []byte) {
+	for i := 0; i < 2; i += 1 {
+		// connecting first, since framing depends on the dialed network
+		if !appender.connection.connect() {
+			return
+		}
+		if appender.connection.write(appender.frame(message)) {
+			return
+		}
+	}
+}
+
+func (appender *syslogAppender) dial() (net.Conn, error) {
+	if appender.network != SyslogNetworkUnix {
+		appender.isStream = appender.network == SyslogNetworkTcp
+		return net.DialTimeout(appender.network, appender.address, dialTimeout)
+	}
+
+	conn, err := net.DialTimeout("unixgram", appender.address, dialTimeout)
+	if err == nil {
+		appender.isStream = false
+		return conn, nil
+	}
+	conn, err = net.DialTimeout("unix", appender.address, dialTimeout)
+	if err == nil {
+		appender.isStream = true
+	}
+	return conn, err
+}
+
+// messages over stream are framed by octet counting of RFC 6587, like `11 <14>1 - ...`
+func (appender *syslogAppender) frame(message []byte) []byte {
+	if !appender.isStream {
+		return message
+	}
+	return append([]byte(strconv.Itoa(len(message))+" "), message...)
+}
+
+type syslogEncoder struct {
+	format           string
+	facility         int
+	appName          string
+	hostname         string
+	structuredDataId string
+
+	// encoder of MSG part
+	message encoder
+}
+
+func newSyslogEncoder(config *AppenderConfig) (*syslogEncoder, error) {
+	syslogConfig := config.Syslog
+
+	format := syslogConfig.Format
+	switch format {
+	case emptyString:
+		format = SyslogFormatRFC5424
+	case SyslogFormatRFC5424, SyslogFormatRFC3164:
+	default:
+		return nil, fmt.Errorf("unsupported syslog format '%s'", format)
+	}
+
+	facilityName := syslogConfig.Facility
+	if facilityName == emptyString {
+		facilityName = defaultSyslogFacility
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported syslog facility '%s'", facilityName)
+	}
+
+	appName := syslogConfig.AppName
+	if appName == emptyString {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	hostname := syslogConfig.Hostname
+	if hostname == emptyString {
+		hostname = hostName
+	}
+
+	structuredDataId := syslogConfig.StructuredDataId
+	if structuredDataId == emptyString {
+		structuredDataId = SyslogDefaultStructuredDataId
+	}
+
+	// only the MSG part is encoded by layout, so that header is not repeated
+	messageConfig := *config
+	if messageConfig.Layout == emptyString {
+		messageConfig.Layout = defaultSyslogLayout
+	}
+	message, err := newEncoder(&messageConfig, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogEncoder{
+		format:           format,
+		facility:         facility,
+		appName:          sanitizeHeaderField(appName, maxAppNameLength),
+		hostname:         sanitizeHeaderField(hostname, maxHostnameLength),
+		structuredDataId: sanitizeHeaderField(structuredDataId, maxParamNameLength),
+		message:          message,
+	}, nil
+}
+
+func (encoder *syslogEncoder) encode(event *LoggingEvent) []byte {
+	buffer := bytes.Buffer{}
+
+	buffer.WriteByte('<')
+	buffer.WriteString(strconv.Itoa(encoder.facility*8 + syslogSeverities[event.Level]))
+	buffer.WriteByte('>')
+
+	if encoder.format == SyslogFormatRFC3164 {
+		buffer.WriteString(event.Timestamp.Format(rfc3164TimestampFormat))
+		buffer.WriteByte(' ')
+		buffer.WriteString(encoder.hostname)
+		buffer.WriteByte(' ')
+		buffer.WriteString(encoder.appName)
+		buffer.WriteString(fmt.Sprintf("[%d]: ", processId))
+	} else {
+		buffer.WriteString("1 ")
+		buffer.WriteString(event.Timestamp.Format(rfc5424TimestampFormat))
+		buffer.WriteByte(' ')
+		buffer.WriteString(encoder.hostname)
+		buffer.WriteByte(' ')
+		buffer.WriteString(encoder.appName)
+		buffer.WriteByte(' ')
+		buffer.WriteString(strconv.Itoa(processId))
+		buffer.WriteByte(' ')
+		buffer.WriteString(syslogNilValue)
+		buffer.WriteByte(' ')
+		encoder.writeStructuredData(&buffer, event)
+		buffer.WriteByte(' ')
+	}
+
+	// one message per event, the line separator of layout is useless
+	buffer.Write(bytes.TrimRight(encoder.message.encode(event), "\r\n"))
+
+	return buffer.Bytes()
+}
+
+// fields and context are written as one SD-ELEMENT, like `[fields@32473 user="foo" traceId="bar"]`
+func (encoder *syslogEncoder) writeStructuredData(buffer *bytes.Buffer, event *LoggingEvent) {
+	fields := mergeFields(event.Fields, event.Context)
+	if len(fields) == 0 {
+		buffer.WriteString(syslogNilValue)
+		return
+	}
+
+	buffer.WriteByte('[')
+	buffer.WriteString(encoder.structuredDataId)
+	for _, field := range fields {
+		buffer.WriteByte(' ')
+		buffer.WriteString(sanitizeParamName(field.Key))
+		buffer.WriteString("=\"")
+		writeParamValue(buffer, stringify(field.Value))
+		buffer.WriteByte('"')
+	}
+	buffer.WriteByte(']')
+}
+
+// header fields only consist of printable US-ASCII, and space is not allowed
+func sanitizeHeaderField(value string, maxLength int) string {
+	if value == emptyString {
+		return syslogNilValue
+	}
+
+	chars := []byte(value)
+	for i, c := range chars {
+		if c <= ' ' || c > '~' {
+			chars[i] = '_'
+		}
+	}
+	if len(chars) > maxLength {
+		chars = chars[:maxLength]
+	}
+	return string(chars)
+}
+
+// PARAM-NAME is a header field which doesn't contain '=', ']' or '"'
+func sanitizeParamName(name string) string {
+	name = sanitizeHeaderField(name, maxParamNameLength)
+
+	chars := []byte(name)
+	for i, c := range chars {
+		if c == '=' || c == ']' || c == '"' {
+			chars[i] = '_'
+		}
+	}
+	return string(chars)
+}
+
+// '"', '\' and ']' must be escaped in PARAM-VALUE
+func writeParamValue(buffer *bytes.Buffer, value string) {
+	for _, c := range value {
+		if c == '"' || c == '\\' || c == ']' {
+			buffer.WriteByte('\\')
+		}
+		buffer.WriteRune(c)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type callerTester struct {
+	logger log.Logger
+}
+
+func (tester *callerTester) log() {
+	tester.logger.Info("from method")
+}
+
+func TestCallerConversions(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%M]-[%C]-[%F]-[%pid]-[%hostname] --- %m%n",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("caller", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	_, file, _, _ := runtime.Caller(0)
+	hostname, _ := os.Hostname()
+	pid := strconv.Itoa(os.Getpid())
+
+	var content string
+
+	logger.Info("from function")
+	(&callerTester{logger: logger}).log()
+	func() {
+		logger.With("key", "value").Info("from closure")
+	}()
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == fmt.Sprintf("[TestCallerConversions]-[github.com/liuyehcf/common-gtools/log/test]-[%s]-[%s]-[%s] --- from function\n", file, pid, hostname)+
+		fmt.Sprintf("[(*callerTester).log]-[github.com/liuyehcf/common-gtools/log/test]-[%s]-[%s]-[%s] --- from method\n", file, pid, hostname)+
+		fmt.Sprintf("[TestCallerConversions.func1]-[github.com/liuyehcf/common-gtools/log/test]-[%s]-[%s]-[%s] --- from closure\n", file, pid, hostname), content)
+}
+
+func TestGoroutineConversions(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%t]-[%thread]-[%r] --- %m%n",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("goroutine", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	stack := make([]byte, 64)
+	stack = stack[:runtime.Stack(stack, false)]
+	goroutineId := strings.Fields(string(stack))[1]
+
+	logger.Info("hello")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(strings.HasPrefix(content, "["+goroutineId+"]-["+goroutineId+"]-["), content)
+
+	elapsed, err := strconv.Atoi(content[strings.LastIndex(content, "[")+1 : strings.LastIndex(content, "]")])
+	utils.AssertTrue(err == nil && elapsed >= 0, content)
+}
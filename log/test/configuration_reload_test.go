@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+const reloadConfig = `
+scan: true
+scanPeriod: 10ms
+appenders:
+  common:
+    type: file
+    layout: "%s [%%p]-[%%c] --- %%m%%n"
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: %s
+      maxHistory: 10
+      maxFileSize: 10MB
+root:
+  level: %s
+  appenders: [common]
+`
+
+func TestReloadConfiguration(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("reload.yaml", fmt.Sprintf(reloadConfig, "v1", "reloadV1", "INFO"))
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	logger := log.GetLogger("reload")
+	utils.AssertFalse(logger.IsDebugEnabled(), "test")
+
+	for i := 0; i < 100; i += 1 {
+		logger.Info("you can see this {}", i)
+	}
+
+	writeConfigFile("reload.yaml", fmt.Sprintf(reloadConfig, "v2", "reloadV2", "DEBUG"))
+	time.Sleep(time.Millisecond * 100)
+	utils.AssertTrue(logger.IsDebugEnabled(), "test")
+
+	logger.Debug("you can see this")
+	time.Sleep(time.Millisecond * 10)
+
+	// all the queued events of the replaced appender are written
+	content := readConfigFile("reloadV1.log")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	utils.AssertTrue(len(lines) == 100, content)
+	utils.AssertTrue(lines[99] == "v1 [INFO]-[reload] --- you can see this 99", content)
+
+	content = readConfigFile("reloadV2.log")
+	utils.AssertTrue(content == "v2 [DEBUG]-[reload] --- you can see this\n", content)
+
+	// invalid configuration is ignored, and the current one is kept
+	writeConfigFile("reload.yaml", "scan: true\nscanPeriod: 10ms\nroot:\n  level: VERBOSE\n")
+	time.Sleep(time.Millisecond * 100)
+	utils.AssertTrue(logger.IsDebugEnabled(), "test")
+
+	// stop scanning
+	writeConfigFile("reload.yaml", fmt.Sprintf(strings.Replace(reloadConfig, "scan: true", "scan: false", 1), "v3", "reloadV3", "WARN"))
+	time.Sleep(time.Millisecond * 100)
+	utils.AssertFalse(logger.IsInfoEnabled(), "test")
+
+	writeConfigFile("reload.yaml", fmt.Sprintf(reloadConfig, "v4", "reloadV4", "TRACE"))
+	time.Sleep(time.Millisecond * 100)
+	utils.AssertFalse(logger.IsInfoEnabled(), "test")
+}
+
+func TestReloadConfigurationWithoutRoot(t *testing.T) {
+	resetConfigDirectory()
+
+	// loggers created by code are kept after reloading
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%c] --- %m%n",
+		Writer: writer,
+	})
+	codeLogger := log.NewLogger("noRoot.code", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	path := writeConfigFile("noRoot.yaml", fmt.Sprintf(reloadConfig, "v1", "noRootV1", "WARN"))
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+	utils.AssertTrue(log.GetLevel(log.Root) == log.WarnLevel, "test")
+
+	// the configured root is replaced by the default one, since its appenders are destroyed
+	path = writeConfigFile("noRoot.yaml", `
+appenders:
+  common:
+    type: file
+    layout: "[%p]-[%c] --- %m%n"
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: noRootV2
+      maxHistory: 10
+      maxFileSize: 10MB
+loggers:
+  noRoot.configured:
+    level: INFO
+    additivity: false
+    appenders: [common]
+`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+	utils.AssertTrue(log.GetLevel(log.Root) == log.InfoLevel, "test")
+	utils.AssertTrue(log.GetLogger("noRoot.other").IsInfoEnabled(), "test")
+
+	log.GetLogger("noRoot.other").Warn("you can see this in stdout")
+	log.GetLogger("noRoot.configured").Info("you can see this")
+	codeLogger.Info("you can see this")
+	time.Sleep(time.Millisecond * 50)
+
+	utils.AssertTrue(readConfigFile("noRootV1.log") == "", readConfigFile("noRootV1.log"))
+	utils.AssertTrue(readConfigFile("noRootV2.log") == "[INFO]-[noRoot.configured] --- you can see this\n",
+		readConfigFile("noRootV2.log"))
+	utils.AssertTrue(writer.ReadString() == "[INFO]-[noRoot.code] --- you can see this\n", "test")
+}
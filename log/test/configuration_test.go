@@ -0,0 +1,413 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const configDirectory = "/tmp/gtools/config"
+
+func TestConfigureFromYaml(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("log.yaml", `
+appenders:
+  common:
+    type: file
+    layout: "[%p]-[%c] --- %m%n"
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: yaml
+      timeGranularity: hour
+      maxHistory: 10
+      maxFileSize: 10MB
+      compress: true
+      totalSizeCap: 1GB
+      maxAge: 7 days
+  error:
+    type: file
+    layout: "[%p]-[%c] --- %m%n"
+    filters:
+      - level: ERROR
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: yamlError
+      fileNamePattern: "yamlError-%d{2006-01}.%i.log"
+      maxHistory: 10
+      maxFileSize: 1048576
+root:
+  level: warn
+  appenders: [common]
+loggers:
+  com.config:
+    level: DEBUG
+    additivity: false
+    appenders: [common, error]
+`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	log.GetLogger("other").Info("you cannot see this")
+	log.GetLogger("other").Warn("you can see this")
+	log.GetLogger("com.config.child").Debug("you can see this")
+	log.GetLogger("com.config").Error("you can see this twice")
+	time.Sleep(time.Millisecond * 10)
+
+	content := readConfigFile("yaml.log")
+	utils.AssertTrue(content == "[WARN]-[other] --- you can see this\n"+
+		"[DEBUG]-[com.config.child] --- you can see this\n"+
+		"[ERROR]-[com.config] --- you can see this twice\n", content)
+	content = readConfigFile("yamlError.log")
+	utils.AssertTrue(content == "[ERROR]-[com.config] --- you can see this twice\n", content)
+}
+
+func TestConfigureFromJson(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("log.json", `{
+  "appenders": {
+    "common": {
+      "type": "file",
+      "layout": "[%p]-[%c] --- %m%n",
+      "rollingPolicy": {"directory": "/tmp/gtools/config", "fileName": "json", "maxHistory": 10, "maxFileSize": "1GB"}
+    }
+  },
+  "root": {"level": "INFO", "appenders": ["common"]}
+}`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	log.GetLogger("json").Debug("you cannot see this")
+	log.GetLogger("json").Info("you can see this")
+	time.Sleep(time.Millisecond * 10)
+
+	content := readConfigFile("json.log")
+	utils.AssertTrue(content == "[INFO]-[json] --- you can see this\n", content)
+}
+
+func TestConfigureFromLogbackXml(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("logback.xml", `<configuration>
+    <appender name="FILE" class="ch.qos.logback.core.rolling.RollingFileAppender">
+        <file>/tmp/gtools/config/xml.log</file>
+        <filter class="ch.qos.logback.classic.filter.ThresholdFilter">
+            <level>INFO</level>
+        </filter>
+        <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+            <fileNamePattern>/tmp/gtools/config/xml.%d{yyyy-MM-dd_HH}.%i.log</fileNamePattern>
+            <maxHistory>10</maxHistory>
+            <maxFileSize>100MB</maxFileSize>
+        </rollingPolicy>
+        <encoder>
+            <pattern>[%p]-[%c] --- %m%n</pattern>
+        </encoder>
+    </appender>
+    <logger name="com.xml" level="DEBUG" additivity="false">
+        <appender-ref ref="FILE"/>
+    </logger>
+    <root level="ERROR">
+        <appender-ref ref="FILE"/>
+    </root>
+</configuration>`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	log.GetLogger("com.xml.child").Debug("you cannot see this")
+	log.GetLogger("com.xml.child").Info("you can see this")
+	log.GetLogger("xml").Warn("you cannot see this")
+	log.GetLogger("xml").Error("you can see this")
+	time.Sleep(time.Millisecond * 10)
+
+	content := readConfigFile("xml.log")
+	utils.AssertTrue(content == "[INFO]-[com.xml.child] --- you can see this\n"+
+		"[ERROR]-[xml] --- you can see this\n", content)
+}
+
+func TestConfigureFileNamePatternFromLogbackXml(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("logback.xml", `<configuration>
+    <appender name="FILE" class="ch.qos.logback.core.rolling.RollingFileAppender">
+        <file>/tmp/gtools/config/audit.log</file>
+        <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+            <fileNamePattern>/tmp/gtools/config/audit-%d{yyyy-MM}.%i.log</fileNamePattern>
+            <maxHistory>100</maxHistory>
+            <maxFileSize>1KB</maxFileSize>
+        </rollingPolicy>
+        <encoder>
+            <pattern>%m%n</pattern>
+        </encoder>
+    </appender>
+    <logger name="com.audit" level="INFO" additivity="false">
+        <appender-ref ref="FILE"/>
+    </logger>
+</configuration>`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	for i := 0; i < 200; i += 1 {
+		log.GetLogger("com.audit").Info("line {}", i)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	// rolled monthly, named by the translated pattern
+	names := listRolledFiles(configDirectory, "audit.log")
+	utils.AssertTrue(len(names) > 1, strings.Join(names, ","))
+	utils.AssertTrue(names[0] == "audit-"+time.Now().Format("2006-01")+".1.log", strings.Join(names, ","))
+}
+
+func TestConfigureFixedWindowFromLogbackXml(t *testing.T) {
+	resetConfigDirectory()
+	path := writeConfigFile("logback.xml", `<configuration>
+    <appender name="FILE" class="ch.qos.logback.core.rolling.RollingFileAppender">
+        <file>/tmp/gtools/config/window.log</file>
+        <rollingPolicy class="ch.qos.logback.core.rolling.FixedWindowRollingPolicy">
+            <fileNamePattern>/tmp/gtools/config/window.%i.log</fileNamePattern>
+            <minIndex>1</minIndex>
+            <maxIndex>2</maxIndex>
+        </rollingPolicy>
+        <triggeringPolicy class="ch.qos.logback.core.rolling.SizeBasedTriggeringPolicy">
+            <maxFileSize>1KB</maxFileSize>
+        </triggeringPolicy>
+        <encoder>
+            <pattern>%m%n</pattern>
+        </encoder>
+    </appender>
+    <logger name="com.window" level="INFO" additivity="false">
+        <appender-ref ref="FILE"/>
+    </logger>
+</configuration>`)
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	for i := 0; i < 1000; i += 1 {
+		log.GetLogger("com.window").Info("line {}", i)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	names := listRolledFiles(configDirectory, "window.log")
+	utils.AssertTrue(strings.Join(names, ",") == "logback.xml,window.1.log,window.2.log", strings.Join(names, ","))
+}
+
+func TestInvalidConfiguration(t *testing.T) {
+	resetConfigDirectory()
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  common:
+    type: file
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: invalid
+      maxHistory: 0
+      maxFileSize: 10MB
+`, "appenders.common.rollingPolicy.maxHistory: must large than 0")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    layuot: "%m%n"
+`, "appenders.stdout.layuot: unknown key")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+loggers:
+  com.acme:
+    level: VERBOSE
+`, "loggers.com.acme.level: unsupported log level 'VERBOSE'")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    encoder: json
+    layout: "%m%n"
+`, "appenders.stdout.layout: only pattern encoder supports layout")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    queueSize: 16
+    overflowPolicy: drop
+`, "appenders.stdout.overflowPolicy: unsupported overflow policy 'drop', only block, dropNewest, dropOldest and discardBelowWarn are supported")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    queueSize: 16
+    overflowPolicy: dropOldest
+    discardingThreshold: 8
+`, "appenders.stdout.discardingThreshold: only overflow policy discardBelowWarn supports discardingThreshold")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    layout: "%d{15:04:05 [%p] %m%n"
+`, "appenders.stdout: invalid layout '%d{15:04:05 [%p] %m%n': unterminated option at column 3")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  syslog:
+    type: syslog
+    syslog:
+      network: udp
+      address: 127.0.0.1:514
+      facility: local8
+`, "appenders.syslog.syslog.facility: unsupported facility 'local8'")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  stdout:
+    type: console
+    syslog:
+      network: udp
+      address: 127.0.0.1:514
+`, "appenders.stdout.syslog: only syslog appender supports syslog")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  socket:
+    type: socket
+    layout: "%m%n"
+    socket:
+      address: 127.0.0.1:4560
+`, "appenders.socket.layout: socket appender sends events without encoding")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  common:
+    type: file
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: invalid
+      fileNamePattern: "invalid.%d{2006-01-02_15-04}{7m}.%i.log"
+      maxHistory: 10
+      maxFileSize: 10MB
+`, "appenders.common.rollingPolicy.fileNamePattern: period '7m' must divide an hour or a day evenly")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  common:
+    type: file
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: invalid
+      timeGranularity: hour
+      fixedWindow: true
+      maxHistory: 10
+      maxFileSize: 10MB
+`, "appenders.common.rollingPolicy.fixedWindow: can not be used with timeGranularity or fileNamePattern")
+
+	assertConfigurationError(t, "log.yaml", `
+appenders:
+  common:
+    type: file
+    rollingPolicy:
+      directory: /tmp/gtools/config
+      fileName: invalid
+      maxHistory: 10
+      maxFileSize: 10MB
+      compress: true
+      prudent: true
+`, "appenders.common.rollingPolicy.prudent: can not be used with compress")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <appender name="FILE" class="ch.qos.logback.core.rolling.RollingFileAppender">
+        <file>/tmp/gtools/config/invalid.log</file>
+        <rollingPolicy class="ch.qos.logback.core.rolling.FixedWindowRollingPolicy">
+            <fileNamePattern>/tmp/gtools/config/invalid.%i.log</fileNamePattern>
+            <minIndex>0</minIndex>
+        </rollingPolicy>
+    </appender>
+</configuration>`, "appender[FILE].rollingPolicy.minIndex: only 1 is supported")
+
+	for _, testCase := range []struct {
+		pattern  string
+		expected string
+	}{
+		{"invalid.%d{yyyy-ww}.%i.log", "appender[FILE].rollingPolicy.fileNamePattern: unsupported date token 'ww'"},
+		{"invalid.%d{yyyy-MM-dd, UTC}.%i.log", "appender[FILE].rollingPolicy.fileNamePattern: unsupported character ',' in date pattern"},
+		{"invalid.%d{yyyy-MM-dd}.log", "appenders.FILE.rollingPolicy.fileNamePattern: file name pattern must contain '%i'"},
+	} {
+		assertConfigurationError(t, "logback.xml", `<configuration>
+    <appender name="FILE" class="ch.qos.logback.core.rolling.RollingFileAppender">
+        <file>/tmp/gtools/config/invalid.log</file>
+        <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+            <fileNamePattern>/tmp/gtools/config/`+testCase.pattern+`</fileNamePattern>
+            <maxHistory>10</maxHistory>
+            <maxFileSize>1KB</maxFileSize>
+        </rollingPolicy>
+    </appender>
+</configuration>`, testCase.expected)
+	}
+
+	assertConfigurationError(t, "log.json", `{
+  "appenders": {"stdout": {"type": "console"}},
+  "root": {"appenders": ["stdout", "missing"]}
+}`, "root.appenders[1]: unknown appender 'missing'")
+
+	assertConfigurationError(t, "log.json", `{"appenders": {"common": {"type": "file", "rollingPolicy": {
+  "directory": "/tmp/gtools/config", "fileName": "invalid", "maxHistory": 1, "maxFileSize": "10XB"}}}}`,
+		"appenders.common.rollingPolicy.maxFileSize: invalid file size '10XB'")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <appender name="ASYNC" class="ch.qos.logback.classic.AsyncAppender"/>
+</configuration>`, "appenders.ASYNC.type: unsupported appender type 'ch.qos.logback.classic.AsyncAppender', only console, file, syslog and socket are supported")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <root>
+        <level value="DEBUG"/>
+    </root>
+</configuration>`, "root.level: unsupported element")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <appender name="STDOUT" class="ch.qos.logback.core.ConsoleAppender">
+        <encoder>
+            <charset>UTF-8</charset>
+            <pattern>%m%n</pattern>
+        </encoder>
+    </appender>
+</configuration>`, "appender[STDOUT].encoder.charset: unsupported element")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <appender name="STDOUT" class="ch.qos.logback.core.ConsoleAppender">
+        <filter class="ch.qos.logback.classic.filter.ThresholdFilter">
+            <level>INFO</level>
+            <onMatch>DENY</onMatch>
+        </filter>
+    </appender>
+</configuration>`, "appender[STDOUT].filter[0].onMatch: unsupported element")
+
+	assertConfigurationError(t, "logback.xml", `<configuration>
+    <logger name="com.acme" level="DEBUG"/>
+    <logger name="com.acme" level="ERROR"/>
+</configuration>`, "logger[com.acme]: duplicate logger")
+
+	assertConfigurationError(t, "log.toml", ``, "unsupported configuration file '/tmp/gtools/config/log.toml', only .yaml, .yml, .json and .xml are supported")
+}
+
+func assertConfigurationError(t *testing.T, fileName string, content string, expected string) {
+	err := log.ConfigureFromFile(writeConfigFile(fileName, content))
+	utils.AssertNotNil(err, expected)
+	utils.AssertTrue(err.Error() == expected, err.Error())
+}
+
+func resetConfigDirectory() {
+	_ = os.RemoveAll(configDirectory)
+	utils.AssertNil(os.MkdirAll(configDirectory, os.ModePerm), "test")
+}
+
+func writeConfigFile(fileName string, content string) string {
+	path := configDirectory + "/" + fileName
+	utils.AssertNil(ioutil.WriteFile(path, []byte(strings.TrimLeft(content, "\n")), 0666), "test")
+	return path
+}
+
+func readConfigFile(fileName string) string {
+	bytes, err := ioutil.ReadFile(configDirectory + "/" + fileName)
+	utils.AssertNil(err, "test")
+	return string(bytes)
+}
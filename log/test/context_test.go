@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tenantContextKey struct{}
+
+func TestContextLogger(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%L]-[%traceId]-[%-4spanId]-[%X] {%fields} --- %m%n",
+		Writer: writer,
+	})
+
+	log.RegisterContextExtractor(func(ctx context.Context) []log.Field {
+		if tenantId, ok := ctx.Value(tenantContextKey{}).(string); ok {
+			return []log.Field{log.NewField("tenantId", tenantId)}
+		}
+		return nil
+	})
+
+	logger := log.NewLogger("context", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	ctx := log.ContextWithTraceId(context.Background(), "t1")
+	ctx = log.ContextWithSpanId(ctx, "s1")
+	ctx = log.ContextWithMdc(ctx, "requestId", "r1")
+	ctx = context.WithValue(ctx, tenantContextKey{}, "tenant1")
+
+	var content string
+
+	logger.Ctx(context.Background()).Info("empty context")
+	logger.Ctx(ctx).Info("with context")
+	logger.With("userId", 1).Ctx(ctx).Warn("with fields and context")
+	logger.Ctx(ctx).With("userId", 2).Debug("you cannot see this")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[INFO]-[context_test.go:38]-[]-[    ]-[] {} --- empty context\n"+
+		"[INFO]-[context_test.go:39]-[t1]-[s1  ]-[requestId=r1] {} --- with context\n"+
+		"[WARN]-[context_test.go:40]-[t1]-[s1  ]-[requestId=r1] {userId=1} --- with fields and context\n", content)
+}
+
+func TestContextJsonEncoder(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Encoder: log.EncoderJson,
+		Writer:  writer,
+	})
+
+	logger := log.NewLogger("contextJson", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	ctx := log.ContextWithTraceId(context.Background(), "t2")
+	logger.Ctx(ctx).Info("with context")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(len(content) > 0 && content[len(content)-1] == '\n', content)
+	utils.AssertTrue(strings.Contains(content, `"context":{"traceId":"t2"}`), content)
+}
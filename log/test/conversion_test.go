@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// conversions and loggers are registered globally and kept across runs, so names are unique in each run,
+// like `go test -count=2`
+var testRun int32
+
+func nextRunSuffix() string {
+	return fmt.Sprintf("%d", atomic.AddInt32(&testRun, 1))
+}
+
+func TestRegisterConversion(t *testing.T) {
+	suffix := nextRunSuffix()
+	err := log.RegisterConversion([]string{"tenant" + suffix, "tn" + suffix}, func(options []string) (log.Conversion, error) {
+		defaultTenant := "-"
+		if len(options) > 0 {
+			defaultTenant = options[0]
+		}
+		return log.ConversionFunc(func(event *log.LoggingEvent) string {
+			if tenant, ok := event.GetContextValue("tenant"); ok {
+				return tenant.(string)
+			}
+			return defaultTenant
+		}), nil
+	})
+	utils.AssertNil(err, "test")
+
+	err = log.RegisterConversion([]string{"build" + suffix}, func(options []string) (log.Conversion, error) {
+		if len(options) > 0 {
+			return nil, errors.New("build does not support options")
+		}
+		return log.ConversionFunc(func(event *log.LoggingEvent) string {
+			return "v1.0.0"
+		}), nil
+	})
+	utils.AssertNil(err, "test")
+
+	log.RegisterContextExtractor(func(ctx context.Context) []log.Field {
+		if tenant, ok := ctx.Value(tenantContextKey{}).(string); ok {
+			return []log.Field{log.NewField("tenant", tenant)}
+		}
+		return nil
+	})
+
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: fmt.Sprintf("[%%-8tenant%s{none}]-[%%tn%s]-[%%.2build%s] --- %%m%%n", suffix, suffix, suffix),
+		Writer: writer,
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("conversion", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger.Info("without tenant")
+	logger.Ctx(context.WithValue(context.Background(), tenantContextKey{}, "acme")).Info("with tenant")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[none    ]-[-]-[.0] --- without tenant\n"+
+		"[acme    ]-[acme]-[.0] --- with tenant\n", content)
+
+	_, err = log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%build" + suffix + "{x} %m%n",
+		Writer: writer,
+	})
+	utils.AssertTrue(err != nil && err.Error() == "invalid layout '%build"+suffix+"{x} %m%n': build does not support options at column 2",
+		"test")
+}
+
+func TestRegisterConversionErrors(t *testing.T) {
+	nilConversion := "nilConversion" + nextRunSuffix()
+	factory := func(options []string) (log.Conversion, error) {
+		return nil, nil
+	}
+
+	utils.AssertTrue(log.RegisterConversion(nil, factory) != nil, "test")
+	utils.AssertTrue(log.RegisterConversion([]string{"valid"}, nil) != nil, "test")
+	utils.AssertTrue(log.RegisterConversion([]string{"in-valid"}, factory) != nil, "test")
+	utils.AssertTrue(log.RegisterConversion([]string{""}, factory) != nil, "test")
+	utils.AssertTrue(log.RegisterConversion([]string{nilConversion, "m"}, factory) != nil, "test")
+	utils.AssertTrue(log.RegisterConversion([]string{"red"}, factory) != nil, "test")
+
+	// nothing is registered if any of the words is invalid
+	utils.AssertNil(log.RegisterConversion([]string{nilConversion}, factory), "test")
+	_, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%" + nilConversion,
+		Writer: log.NewStringWriter(buffer.NewRecycleByteBuffer(1024)),
+	})
+	utils.AssertTrue(err != nil && strings.Contains(err.Error(), "factory of conversion returns nil"), "test")
+}
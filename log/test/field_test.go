@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestWithFields(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%c]-[%L] {%fields} --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger("fields", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger := log.GetLogger("fields")
+	childLogger := logger.With("userId", 1, "tenantId", "t1")
+	grandchildLogger := childLogger.With(log.NewField("requestId", "r1"))
+
+	var content string
+
+	logger.Info("no fields")
+	childLogger.Info("with fields")
+	grandchildLogger.Info("user {} login", "tom", log.NewField("ip", "127.0.0.1"))
+	childLogger.Debug("you cannot see this")
+	utils.AssertTrue(childLogger.Name() == "fields", "test")
+	utils.AssertFalse(grandchildLogger.IsDebugEnabled(), "test")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[INFO]-[fields]-[field_test.go:26] {} --- no fields\n"+
+		"[INFO]-[fields]-[field_test.go:27] {userId=1, tenantId=t1} --- with fields\n"+
+		"[INFO]-[fields]-[field_test.go:28] {userId=1, tenantId=t1, requestId=r1, ip=127.0.0.1} --- user tom login\n", content)
+
+	// child loggers follow the replaced logger
+	log.NewLogger("fields", log.DebugLevel, false, []log.Appender{writerAppender})
+	utils.AssertTrue(grandchildLogger.IsDebugEnabled(), "test")
+	childLogger.Debug("you can see this")
+	logger.With("odd").Info("odd key values")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[DEBUG]-[fields]-[field_test.go:41] {userId=1, tenantId=t1} --- you can see this\n"+
+		"[INFO]-[fields]-[field_test.go:42] {!BADKEY=odd} --- odd key values\n", content)
+}
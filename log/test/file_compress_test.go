@@ -0,0 +1,139 @@
+package main
+
+import (
+	"compress/gzip"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressRolledFiles(t *testing.T) {
+	directory := "/tmp/gtools/compress"
+	compressedNum, lineNum := rollingWithCompression(directory, 100)
+
+	utils.AssertTrue(compressedNum > 1, "test")
+	utils.AssertTrue(lineNum == 1000, "test")
+}
+
+func TestCompressRolledFilesWithHistory(t *testing.T) {
+	directory := "/tmp/gtools/compressHistory"
+	compressedNum, lineNum := rollingWithCompression(directory, 3)
+
+	utils.AssertTrue(compressedNum <= 3, "test")
+	utils.AssertTrue(lineNum < 1000, "test")
+}
+
+// return number of compressed files and number of lines in all files
+func rollingWithCompression(directory string, history int) (int, int) {
+	_ = os.RemoveAll(directory)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "compress",
+			MaxHistory:  history,
+			MaxFileSize: 1024,
+			Compress:    true,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("compress", log.InfoLevel, false, []log.Appender{fileAppender})
+	for i := 0; i < 1000; i += 1 {
+		logger.Info("line {}", i)
+	}
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 200)
+
+	fileInfos, err := ioutil.ReadDir(directory)
+	utils.AssertNil(err, "test")
+
+	compressedNum := 0
+	lineNum := 0
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		path := directory + "/" + name
+
+		if name == "compress.log" {
+			lineNum += countLines(path, false)
+			continue
+		}
+
+		// rolled files are all compressed, and no temporary file is left
+		utils.AssertTrue(strings.HasSuffix(name, ".log.gz"), name)
+		utils.AssertTrue(len(strings.Split(name, ".")) == 5, name)
+		compressedNum += 1
+		lineNum += countLines(path, true)
+	}
+
+	return compressedNum, lineNum
+}
+
+func countLines(path string, isCompressed bool) int {
+	file, err := os.Open(path)
+	utils.AssertNil(err, "test")
+	defer file.Close()
+
+	if !isCompressed {
+		content, err := ioutil.ReadAll(file)
+		utils.AssertNil(err, "test")
+		return strings.Count(string(content), "\n")
+	}
+
+	reader, err := gzip.NewReader(file)
+	utils.AssertNil(err, "test")
+	content, err := ioutil.ReadAll(reader)
+	utils.AssertNil(err, "test")
+	return strings.Count(string(content), "\n")
+}
+
+func TestRecoverInterruptedCompressions(t *testing.T) {
+	directory := "/tmp/gtools/compressRecover"
+	_ = os.RemoveAll(directory)
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	// crashed while compressing the first file, and after compressing the second one
+	utils.AssertNil(ioutil.WriteFile(directory+"/compress.2020-01-02.1.log", []byte("1\n2\n"), 0666), "test")
+	writeRolledFile(directory, "compress.2020-01-02.1.log.gz.tmp", 10)
+	writeRolledFile(directory, "compress.2020-01-02.2.log", 10)
+	writeCompressedFile(directory+"/compress.2020-01-02.2.log.gz", "3\n")
+
+	// temporary file of others is kept
+	writeRolledFile(directory, "other.2020-01-02.1.log.gz.tmp", 10)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "compress",
+			MaxHistory:  10,
+			MaxFileSize: 1024,
+			Compress:    true,
+		},
+	})
+	utils.AssertNil(err, "test")
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 100)
+
+	names := listRolledFiles(directory, "compress.log")
+	utils.AssertTrue(strings.Join(names, ",") == "compress.2020-01-02.1.log.gz,compress.2020-01-02.2.log.gz,"+
+		"other.2020-01-02.1.log.gz.tmp", strings.Join(names, ","))
+	utils.AssertTrue(countLines(directory+"/compress.2020-01-02.1.log.gz", true) == 2, "test")
+	utils.AssertTrue(countLines(directory+"/compress.2020-01-02.2.log.gz", true) == 1, "test")
+}
+
+func writeCompressedFile(path string, content string) {
+	file, err := os.Create(path)
+	utils.AssertNil(err, "test")
+	defer file.Close()
+
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte(content))
+	utils.AssertNil(err, "test")
+	utils.AssertNil(writer.Close(), "test")
+}
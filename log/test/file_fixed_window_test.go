@@ -0,0 +1,111 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFixedWindowRolling(t *testing.T) {
+	directory := "/tmp/gtools/fixedWindow"
+	rollingWithFixedWindow(directory, false)
+
+	names := listRolledFiles(directory, "window.log")
+	utils.AssertTrue(strings.Join(names, ",") == "window.1.log,window.2.log,window.3.log", strings.Join(names, ","))
+	assertWindowOrder(directory, []string{"window.3.log", "window.2.log", "window.1.log", "window.log"}, false)
+}
+
+func TestFixedWindowRollingWithCompression(t *testing.T) {
+	directory := "/tmp/gtools/fixedWindowCompress"
+	rollingWithFixedWindow(directory, true)
+
+	names := listRolledFiles(directory, "window.log")
+	utils.AssertTrue(strings.Join(names, ",") == "window.1.log.gz,window.2.log.gz,window.3.log.gz", strings.Join(names, ","))
+	assertWindowOrder(directory, []string{"window.3.log.gz", "window.2.log.gz", "window.1.log.gz", "window.log"}, true)
+}
+
+func TestFixedWindowWithMaxAge(t *testing.T) {
+	_, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   "/tmp/gtools/fixedWindowMaxAge",
+			FileName:    "window",
+			FixedWindow: true,
+			MaxHistory:  3,
+			MaxFileSize: 1024,
+			MaxAge:      time.Hour,
+		},
+	})
+	utils.AssertNotNil(err, "test")
+}
+
+func rollingWithFixedWindow(directory string, compress bool) {
+	_ = os.RemoveAll(directory)
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	// dated files of other policies are not taken as rolled files
+	writeRolledFile(directory, "window.2020-01-02.1.log", 10)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "window",
+			FixedWindow: true,
+			MaxHistory:  3,
+			MaxFileSize: 1024,
+			Compress:    compress,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("fixedWindow", log.InfoLevel, false, []log.Appender{fileAppender})
+	for i := 0; i < 3000; i += 1 {
+		logger.Info("{}", i)
+	}
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 100)
+
+	utils.AssertNil(os.Remove(directory+"/window.2020-01-02.1.log"), "test")
+}
+
+// lines of files are continuous from the oldest file to the current one, the earliest lines are removed,
+// and the last line is written
+func assertWindowOrder(directory string, names []string, isCompressed bool) {
+	expected := -1
+	for _, name := range names {
+		lines := readWindowLines(directory+"/"+name, isCompressed && name != "window.log")
+		utils.AssertTrue(len(lines) > 0, name)
+
+		for _, line := range lines {
+			var value int
+			_, err := fmt.Sscanf(line, "%d", &value)
+			utils.AssertNil(err, line)
+			utils.AssertTrue((expected < 0 && value > 0) || value == expected, line)
+			expected = value + 1
+		}
+	}
+	utils.AssertTrue(expected == 3000, "test")
+}
+
+func readWindowLines(path string, isCompressed bool) []string {
+	file, err := os.Open(path)
+	utils.AssertNil(err, "test")
+	defer file.Close()
+
+	var reader io.Reader = file
+	if isCompressed {
+		reader, err = gzip.NewReader(file)
+		utils.AssertNil(err, "test")
+	}
+	content, err := ioutil.ReadAll(reader)
+	utils.AssertNil(err, "test")
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonthlyFileNamePattern(t *testing.T) {
+	directory := "/tmp/gtools/monthlyPattern"
+	_ = os.RemoveAll(directory)
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	// archives of previous months, and a file which does not match the pattern
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	lastMonth := thisMonth.AddDate(0, -1, 0).Format("2006-01")
+	writeRolledFile(directory, fmt.Sprintf("audit-%s.1.log", thisMonth.AddDate(0, -3, 0).Format("2006-01")), 10)
+	writeRolledFile(directory, fmt.Sprintf("audit-%s.1.log", lastMonth), 10)
+	writeRolledFile(directory, fmt.Sprintf("audit-%s.2.log.gz", lastMonth), 10)
+	writeRolledFile(directory, "audit.2020-01-02.1.log", 10)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:       directory,
+			FileName:        "audit",
+			FileNamePattern: "audit-%d{2006-01}.%i.log",
+			MaxHistory:      5,
+			MaxFileSize:     1024,
+			MaxAge:          time.Hour * 24 * 40,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	// archive of 3 months ago is expired
+	names := listRolledFiles(directory, "audit.log")
+	utils.AssertTrue(len(names) == 3, strings.Join(names, ","))
+
+	logger := log.NewLogger("monthlyPattern", log.InfoLevel, false, []log.Appender{fileAppender})
+	for i := 0; i < 1000; i += 1 {
+		logger.Info("line {}", i)
+	}
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 50)
+
+	// archives of last month are removed first, and indexes of this month are continuous,
+	// the file which does not match the pattern is neither counted nor removed
+	names = listRolledFiles(directory, "audit.log")
+	utils.AssertTrue(len(names) == 6, strings.Join(names, ","))
+	utils.AssertTrue(names[5] == "audit.2020-01-02.1.log", strings.Join(names, ","))
+
+	indexes := make([]int, 0)
+	for _, name := range names[:5] {
+		var index int
+		_, err := fmt.Sscanf(strings.TrimPrefix(name, "audit-"+thisMonth.Format("2006-01")+"."), "%d.log", &index)
+		utils.AssertNil(err, name)
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	utils.AssertTrue(indexes[0] > 1, "test")
+	for i := 1; i < len(indexes); i += 1 {
+		utils.AssertTrue(indexes[i] == indexes[i-1]+1, "test")
+	}
+}
+
+func TestFileNamePatternWithTimeGranularity(t *testing.T) {
+	_, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:       "/tmp/gtools/invalidPattern",
+			FileName:        "debug",
+			TimeGranularity: log.TimeGranularityHour,
+			FileNamePattern: "debug-%d{2006-01-02_15-04}{10m}.%i.log",
+			MaxHistory:      5,
+			MaxFileSize:     1024,
+		},
+	})
+	utils.AssertNotNil(err, "test")
+}
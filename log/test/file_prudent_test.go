@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrudentAppendersSharingFile(t *testing.T) {
+	directory := "/tmp/gtools/prudent"
+	_ = os.RemoveAll(directory)
+
+	// appenders of the same file behave like appenders of different processes
+	names := []string{"first", "second", "third", "fourth"}
+	appenders := make([]log.Appender, 0)
+	for range names {
+		fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+			Layout: "%m%n",
+			FileRollingPolicy: &log.RollingPolicy{
+				Directory:   directory,
+				FileName:    "prudent",
+				MaxHistory:  1000,
+				MaxFileSize: 256,
+				Prudent:     true,
+			},
+		})
+		utils.AssertNil(err, "test")
+		appenders = append(appenders, fileAppender)
+	}
+
+	group := new(sync.WaitGroup)
+	for i, name := range names {
+		logger := log.NewLogger("prudent."+name, log.InfoLevel, false, []log.Appender{appenders[i]})
+		group.Add(1)
+		go func(name string) {
+			defer group.Done()
+			for i := 0; i < 1000; i += 1 {
+				logger.Info("{}-{}", name, i)
+			}
+		}(name)
+	}
+	group.Wait()
+	utils.AssertNil(log.Flush(time.Second*10), "test")
+	for _, appender := range appenders {
+		appender.Destroy()
+	}
+
+	// no line is lost or broken, and no rolled file exceeds the limit
+	lines := make(map[string]int, 0)
+	fileInfos, err := ioutil.ReadDir(directory)
+	utils.AssertNil(err, "test")
+	for _, fileInfo := range fileInfos {
+		if fileInfo.Name() == "prudent.lock" {
+			continue
+		}
+		utils.AssertTrue(fileInfo.Size() < 256+16, fileInfo.Name())
+
+		content, err := ioutil.ReadFile(directory + "/" + fileInfo.Name())
+		utils.AssertNil(err, "test")
+		for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			lines[line] += 1
+		}
+	}
+	utils.AssertTrue(len(fileInfos) > 10, "test")
+	utils.AssertTrue(len(lines) == 4000, fmt.Sprintf("%d", len(lines)))
+	for _, name := range names {
+		for i := 0; i < 1000; i += 1 {
+			line := fmt.Sprintf("%s-%d", name, i)
+			utils.AssertTrue(lines[line] == 1, line)
+		}
+	}
+}
+
+func TestPrudentWithCompression(t *testing.T) {
+	_, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   "/tmp/gtools/prudentCompress",
+			FileName:    "prudent",
+			MaxHistory:  10,
+			MaxFileSize: 1024,
+			Compress:    true,
+			Prudent:     true,
+		},
+	})
+	utils.AssertNotNil(err, "test")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxAgeAtStartup(t *testing.T) {
+	directory := "/tmp/gtools/maxAge"
+	_ = os.RemoveAll(directory)
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	now := time.Now()
+	for i := 0; i < 5; i += 1 {
+		day := now.AddDate(0, 0, -i).Format("2006-01-02")
+		writeRolledFile(directory, fmt.Sprintf("age.%s.1.log", day), 10)
+		writeRolledFile(directory, fmt.Sprintf("age.%s.2.log.gz", day), 10)
+	}
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "age",
+			MaxHistory:  100,
+			MaxFileSize: 1024,
+			MaxAge:      time.Hour * 24,
+		},
+	})
+	utils.AssertNil(err, "test")
+	fileAppender.Destroy()
+
+	// files of today and yesterday are kept, since the end of yesterday is within 24 hours
+	names := listRolledFiles(directory, "age.log")
+	utils.AssertTrue(len(names) == 4, strings.Join(names, ","))
+	for _, name := range names {
+		day := strings.Split(name, ".")[1]
+		utils.AssertTrue(day == now.Format("2006-01-02") || day == now.AddDate(0, 0, -1).Format("2006-01-02"), name)
+	}
+}
+
+func TestTotalSizeCap(t *testing.T) {
+	directory := "/tmp/gtools/totalSizeCap"
+	_ = os.RemoveAll(directory)
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	day := time.Now().Format("2006-01-02")
+	for i := 1; i <= 5; i += 1 {
+		writeRolledFile(directory, fmt.Sprintf("cap.%s.%d.log", day, i), 1024)
+	}
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:    directory,
+			FileName:     "cap",
+			MaxHistory:   100,
+			MaxFileSize:  1024,
+			TotalSizeCap: 1024*3 + 512,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	// the oldest files are removed at startup
+	names := listRolledFiles(directory, "cap.log")
+	utils.AssertTrue(strings.Join(names, ",") == fmt.Sprintf("cap.%s.3.log,cap.%s.4.log,cap.%s.5.log", day, day, day),
+		strings.Join(names, ","))
+
+	// and at each rolling
+	logger := log.NewLogger("totalSizeCap", log.InfoLevel, false, []log.Appender{fileAppender})
+	for i := 0; i < 1000; i += 1 {
+		logger.Info("line {}", i)
+	}
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 50)
+
+	var totalSize int64
+	fileInfos, err := ioutil.ReadDir(directory)
+	utils.AssertNil(err, "test")
+	for _, fileInfo := range fileInfos {
+		if fileInfo.Name() != "cap.log" {
+			totalSize += fileInfo.Size()
+		}
+	}
+	utils.AssertTrue(totalSize <= 1024*3+512, "test")
+	utils.AssertTrue(len(fileInfos) > 3, "test")
+}
+
+func writeRolledFile(directory string, name string, size int) {
+	utils.AssertNil(ioutil.WriteFile(directory+"/"+name, make([]byte, size), 0666), "test")
+}
+
+// names of rolled files in order
+func listRolledFiles(directory string, currentName string) []string {
+	fileInfos, err := ioutil.ReadDir(directory)
+	utils.AssertNil(err, "test")
+
+	names := make([]string, 0)
+	for _, fileInfo := range fileInfos {
+		if fileInfo.Name() != currentName {
+			names = append(names, fileInfo.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -32,7 +33,7 @@ func TestRollingByDay(t *testing.T) {
 func rolling(timeGranularity int, history int, fileAssert func(os.FileInfo)) {
 	direct := "/tmp/gtools/logs"
 	fileName := "rolling"
-	stop := false
+	stop := int32(0)
 
 	commonFileAppender, _ := log.NewFileAppender(&log.AppenderConfig{
 		Layout:  "%d{2006-01-02 15:04:05.999} [%p] %m%n",
@@ -53,7 +54,7 @@ func rolling(timeGranularity int, history int, fileAssert func(os.FileInfo)) {
 	utils.AssertNil(err, "test")
 
 	go func() {
-		for !stop {
+		for atomic.LoadInt32(&stop) == 0 {
 			logger.Info("now: '{}'", time.Now())
 
 			time.Sleep(time.Microsecond)
@@ -63,7 +64,7 @@ func rolling(timeGranularity int, history int, fileAssert func(os.FileInfo)) {
 	time.Sleep(time.Second)
 
 	go func() {
-		for !stop {
+		for atomic.LoadInt32(&stop) == 0 {
 			fileInfos, err := ioutil.ReadDir(direct)
 			if err != nil {
 				time.Sleep(time.Microsecond)
@@ -88,7 +89,7 @@ func rolling(timeGranularity int, history int, fileAssert func(os.FileInfo)) {
 	time.Sleep(time.Second * 3)
 
 	commonFileAppender.Destroy()
-	stop = true
+	atomic.StoreInt32(&stop, 1)
 
 	time.Sleep(time.Millisecond * 10)
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	directory := "/tmp/gtools/flush"
+	_ = os.RemoveAll(directory)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "flush",
+			MaxHistory:  1,
+			MaxFileSize: 1024 * 1024,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("flush", log.InfoLevel, false, []log.Appender{fileAppender})
+
+	for i := 0; i < 1000; i += 1 {
+		logger.Info("line {}", i)
+	}
+	utils.AssertNil(log.Flush(time.Second), "test")
+
+	content, err := ioutil.ReadFile(directory + "/flush.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(strings.Count(string(content), "\n") == 1000, "test")
+}
+
+func TestFlushTimeout(t *testing.T) {
+	writer := newBlockingWriter()
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("flushTimeout", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger.Info("1")
+	logger.Info("2")
+	err := log.Flush(time.Millisecond * 10)
+	utils.AssertTrue(err != nil && err.Error() == "flush timeout, 2 events are not written", "test")
+
+	close(writer.release)
+	utils.AssertNil(log.Flush(time.Second), "test")
+	utils.AssertTrue(writer.ReadString() == "1\n2\n", "test")
+}
+
+func TestDestroyDrainsQueue(t *testing.T) {
+	directory := "/tmp/gtools/destroy"
+	_ = os.RemoveAll(directory)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:   directory,
+			FileName:    "destroy",
+			MaxHistory:  1,
+			MaxFileSize: 1024 * 1024,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("destroy", log.InfoLevel, false, []log.Appender{fileAppender})
+
+	for i := 0; i < 1000; i += 1 {
+		logger.Info("line {}", i)
+	}
+	fileAppender.Destroy()
+	time.Sleep(time.Millisecond * 100)
+
+	content, err := ioutil.ReadFile(directory + "/destroy.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(strings.Count(string(content), "\n") == 1000, "test")
+}
+
+// value of non-comparable type
+type sliceAppender struct {
+	events []string
+}
+
+func (appender sliceAppender) DoAppend(event *log.LoggingEvent) {
+}
+
+func (appender sliceAppender) Destroy() {
+}
+
+func TestFlushWithNonComparableAppenders(t *testing.T) {
+	log.NewLogger("flushNonComparable.first", log.InfoLevel, false, []log.Appender{sliceAppender{}})
+	log.NewLogger("flushNonComparable.second", log.InfoLevel, false, []log.Appender{sliceAppender{}})
+
+	utils.AssertNil(log.Flush(time.Second), "test")
+}
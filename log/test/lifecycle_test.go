@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppenderLifecycle(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		Writer: writer,
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("lifecycle", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	utils.AssertTrue(writerAppender.IsStarted(), "test")
+	utils.AssertNil(writerAppender.Start(), "test")
+
+	logger.Info("before stop")
+	writerAppender.Stop()
+	writerAppender.Stop()
+	writerAppender.Destroy()
+	utils.AssertFalse(writerAppender.IsStarted(), "test")
+	utils.AssertTrue(writerAppender.Start() != nil, "test")
+
+	logger.Info("after stop")
+	time.Sleep(time.Millisecond * 10)
+	utils.AssertTrue(writer.ReadString() == "before stop\n", "test")
+}
+
+func TestStopWhileAppending(t *testing.T) {
+	writer := newBlockingWriter()
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout:    "%m%n",
+		Writer:    writer,
+		QueueSize: 1,
+	})
+
+	logger := log.NewLogger("stopWhileAppending", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	// most of the callers are blocked on the full queue
+	group := sync.WaitGroup{}
+	for i := 0; i < 10; i += 1 {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 100; j += 1 {
+				logger.Info("line")
+			}
+		}()
+	}
+	<-writer.started
+
+	for i := 0; i < 10; i += 1 {
+		go writerAppender.Stop()
+	}
+
+	// callers are woken up by stop, even if the writer never returns
+	group.Wait()
+	utils.AssertFalse(writerAppender.IsStarted(), "test")
+	close(writer.release)
+}
+
+func TestConcurrentLoggingAndReconfiguration(t *testing.T) {
+	directory := "/tmp/gtools/lifecycle"
+	_ = os.RemoveAll(directory)
+	path := directory + "/logback.yaml"
+	utils.AssertNil(os.MkdirAll(directory, os.ModePerm), "test")
+
+	stop := make(chan struct{})
+	group := sync.WaitGroup{}
+	for i := 0; i < 8; i += 1 {
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			logger := log.GetLogger(fmt.Sprintf("com.lifecycle.%d", i%2))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				logger.Info("line")
+				logger.With("index", i).Warn("line")
+			}
+		}(i)
+	}
+
+	// root is declared as well, so that warnings of replacing loggers are not written by the root left by other tests
+	for i := 0; i < 20; i += 1 {
+		content := fmt.Sprintf(`appenders:
+  file:
+    type: file
+    layout: "%%m%%n"
+    queueSize: 16
+    rollingPolicy:
+      directory: %s
+      fileName: lifecycle%d
+      maxHistory: 1
+      maxFileSize: 1MB
+root:
+  level: INFO
+  appenders: [file]
+loggers:
+  com.lifecycle:
+    level: INFO
+    appenders: [file]
+`, directory, i)
+		utils.AssertNil(ioutil.WriteFile(path, []byte(content), 0644), "test")
+		utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+		utils.AssertNil(log.SetLevel(fmt.Sprintf("com.lifecycle.%d", i%2), log.WarnLevel), "test")
+
+		writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+			Layout: "%m%n",
+			Writer: log.NewStringWriter(buffer.NewRecycleByteBuffer(1024)),
+		})
+		log.NewLogger("com.lifecycle.1", log.InfoLevel, true, []log.Appender{writerAppender})
+		time.Sleep(time.Millisecond)
+		writerAppender.Destroy()
+	}
+
+	close(stop)
+	group.Wait()
+}
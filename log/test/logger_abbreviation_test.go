@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestLoggerAbbreviation(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%c]-[%c{0}]-[%logger{25}]-[%-12c{10}]-[%c{1}] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger("github.com/liuyehcf/common-gtools/log", log.InfoLevel, false, []log.Appender{writerAppender})
+	log.NewLogger("abbreviation", log.InfoLevel, false, []log.Appender{writerAppender})
+	log.NewLogger("com.example.服务.handler", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	var content string
+
+	log.GetLogger("github.com/liuyehcf/common-gtools/log").Info("import path")
+	log.GetLogger("abbreviation").Info("single segment")
+	log.GetLogger("com.example.服务.handler").Info("chinese")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[github.com/liuyehcf/common-gtools/log]-[log]-[g.c/l/common-gtools/log]-[g.c/l/c/log ]-[g.c/l/c/log] --- import path\n"+
+		"[abbreviation]-[abbreviation]-[abbreviation]-[abbreviation]-[abbreviation] --- single segment\n"+
+		"[com.example.服务.handler]-[handler]-[com.example.服务.handler]-[c.e.服.handler]-[c.e.服.handler] --- chinese\n", content)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestHierarchyInheritance(t *testing.T) {
+	rootWriter := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	rootAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "root [%p]-[%c] --- %m%n",
+		Writer: rootWriter,
+	})
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "acme [%p]-[%c] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{rootAppender})
+
+	// configured loggers are kept after the test
+	com := "com" + nextRunSuffix()
+
+	childLogger := log.GetLogger(com + ".acme.db")
+	otherLogger := log.GetLogger(com + ".other")
+
+	var content string
+
+	childLogger.Debug("you cannot see this")
+	time.Sleep(time.Millisecond * 10)
+	content = rootWriter.ReadString()
+	utils.AssertTrue(content == "", content)
+
+	log.NewLogger(com+".acme", log.DebugLevel, true, []log.Appender{writerAppender})
+
+	utils.AssertTrue(childLogger.IsDebugEnabled(), "test")
+	utils.AssertFalse(otherLogger.IsDebugEnabled(), "test")
+
+	childLogger.Debug("you can see this twice")
+	otherLogger.Info("you can see this once")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "acme [DEBUG]-["+com+".acme.db] --- you can see this twice\n", content)
+	content = rootWriter.ReadString()
+	utils.AssertTrue(content == "root [DEBUG]-["+com+".acme.db] --- you can see this twice\n"+
+		"root [INFO]-["+com+".other] --- you can see this once\n", content)
+
+	// nearest configured ancestor wins
+	log.NewLogger(com+".acme.db", log.ErrorLevel, false, nil)
+	childLogger.Warn("you cannot see this")
+	log.GetLogger(com + ".acme.db.pool").Warn("you cannot see this")
+	log.GetLogger(com + ".acme.web").Warn("you can see this twice")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "acme [WARN]-["+com+".acme.web] --- you can see this twice\n", content)
+	content = rootWriter.ReadString()
+	utils.AssertTrue(content == "root [WARN]-["+com+".acme.web] --- you can see this twice\n", content)
+}
+
+func TestHierarchyNonAdditivity(t *testing.T) {
+	rootWriter := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	rootAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "root [%p]-[%c] --- %m%n",
+		Writer: rootWriter,
+	})
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "biz [%p]-[%c] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger("biz", log.InfoLevel, false, []log.Appender{writerAppender})
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{rootAppender})
+
+	log.GetLogger("biz.order.service").Info("you can see this once")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "biz [INFO]-[biz.order.service] --- you can see this once\n", content)
+	content = rootWriter.ReadString()
+	utils.AssertTrue(content == "", content)
+}
+
+func TestRootNameIgnoresCase(t *testing.T) {
+	rootWriter := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	rootAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "root [%p]-[%c] --- %m%n",
+		Writer: rootWriter,
+	})
+
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{rootAppender})
+
+	// loggers named like root are the root logger itself, rather than shadow loggers replacing it
+	log.GetLogger("root").Info("you can see this")
+	log.GetLogger("Root").Debug("you cannot see this")
+	log.GetLogger(log.Root).Info("you can see this too")
+	time.Sleep(time.Millisecond * 10)
+	content := rootWriter.ReadString()
+	utils.AssertTrue(content == "root [INFO]-[ROOT] --- you can see this\n"+
+		"root [INFO]-[ROOT] --- you can see this too\n", content)
+	utils.AssertTrue(log.GetLevel(log.Root) == log.InfoLevel, "test")
+}
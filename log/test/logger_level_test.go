@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestSetLevel(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%c] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{writerAppender})
+
+	// configured loggers are kept after the test
+	name := "level" + nextRunSuffix()
+	logger := log.NewLogger(name, log.InfoLevel, true, nil)
+	childLogger := log.GetLogger(name + ".child")
+
+	var content string
+
+	utils.AssertTrue(logger.Level() == log.InfoLevel, "test")
+	utils.AssertTrue(childLogger.Level() == log.InfoLevel, "test")
+	utils.AssertTrue(log.GetLevel(name+".child.notExist") == log.InfoLevel, "test")
+
+	utils.AssertNil(log.SetLevel(name, log.DebugLevel), "test")
+	utils.AssertTrue(logger.Level() == log.DebugLevel, "test")
+	utils.AssertTrue(childLogger.Level() == log.DebugLevel, "test")
+	utils.AssertTrue(log.GetLevel(name+".child.notExist") == log.DebugLevel, "test")
+	utils.AssertTrue(log.GetLevel(log.Root) == log.InfoLevel, "test")
+
+	logger.Debug("you can see this")
+	childLogger.Debug("you can see this")
+	childLogger.Trace("you cannot see this")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[DEBUG]-["+name+"] --- you can see this\n"+
+		"[DEBUG]-["+name+".child] --- you can see this\n", content)
+
+	// the shadow logger becomes a configured one
+	utils.AssertNil(log.SetLevel(name+".child", log.ErrorLevel), "test")
+	utils.AssertTrue(childLogger.Level() == log.ErrorLevel, "test")
+	utils.AssertTrue(log.GetLevel(name+".child.notExist") == log.ErrorLevel, "test")
+	utils.AssertTrue(logger.Level() == log.DebugLevel, "test")
+
+	childLogger.Warn("you cannot see this")
+	log.GetLogger(name + ".child.notExist").Error("you can see this")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[ERROR]-["+name+".child.notExist] --- you can see this\n", content)
+
+	utils.AssertNotNil(log.SetLevel(name, 0), "test")
+	utils.AssertNotNil(log.SetLevel(name, log.ErrorLevel+1), "test")
+}
+
+func TestSetRootLevel(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%c] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{writerAppender})
+	logger := log.GetLogger("rootLevel")
+
+	utils.AssertNil(log.SetLevel("root", log.TraceLevel), "test")
+	utils.AssertTrue(logger.IsTraceEnabled(), "test")
+
+	logger.Trace("you can see this")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[TRACE]-[rootLevel] --- you can see this\n", content)
+
+	utils.AssertNil(log.SetLevel(log.Root, log.InfoLevel), "test")
+	utils.AssertFalse(logger.IsTraceEnabled(), "test")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"testing"
+	"time"
+)
+
+func TestMaxWidth(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%-8.10c]-[%.-5c]-[%10.4m]-[%.3p] --- %m%n",
+		Writer: writer,
+	})
+
+	log.NewLogger("width", log.InfoLevel, false, []log.Appender{writerAppender})
+	log.NewLogger("github.com/liuyehcf", log.InfoLevel, false, []log.Appender{writerAppender})
+	log.NewLogger("日志记录器名称很长很长", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	var content string
+
+	log.GetLogger("width").Info("hi")
+	log.GetLogger("github.com/liuyehcf").Warn("hello")
+	log.GetLogger("日志记录器名称很长很长").Error("你好世界啊")
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[width   ]-[width]-[        hi]-[NFO] --- hi\n"+
+		"[m/liuyehcf]-[githu]-[      ello]-[ARN] --- hello\n"+
+		"[志记录器名称很长很长]-[日志记录器]-[      好世界啊]-[ROR] --- 你好世界啊\n", content)
+
+	_, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%.c %m%n",
+		Writer: writer,
+	})
+	utils.AssertTrue(err != nil, "test")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMdc(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%X{requestId}]-[%-20mdc] --- %m%n",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("mdc", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	var content string
+
+	logger.Info("no mdc")
+	log.MdcPut("requestId", "r1")
+	log.MdcPut("tenantId", "t1")
+	utils.AssertTrue(log.MdcGet("requestId") == "r1", "test")
+	logger.Info("with mdc")
+
+	// mdc is bound to goroutine
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		utils.AssertTrue(log.MdcGet("requestId") == "", "test")
+		logger.Info("other goroutine")
+	}()
+	wg.Wait()
+
+	log.MdcRemove("tenantId")
+	logger.Info("remove mdc")
+	log.MdcClear()
+	logger.Info("clear mdc")
+
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[]-[                    ] --- no mdc\n"+
+		"[r1]-[requestId=r1, tenantId=t1] --- with mdc\n"+
+		"[]-[                    ] --- other goroutine\n"+
+		"[r1]-[requestId=r1        ] --- remove mdc\n"+
+		"[]-[                    ] --- clear mdc\n", content)
+}
+
+func TestMdcContext(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%X] --- %m%n",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("mdcContext", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	ctx := log.ContextWithMdc(context.Background(), "requestId", "r2")
+	ctx = log.ContextWithMdc(ctx, "userId", "u2")
+	utils.AssertTrue(len(log.MdcFromContext(ctx)) == 2, "test")
+	utils.AssertTrue(len(log.MdcFromContext(context.Background())) == 0, "test")
+
+	log.MdcPut("tenantId", "t2")
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer log.MdcBind(ctx)()
+		logger.Info("bound")
+	}()
+	wg.Wait()
+
+	func() {
+		defer log.MdcBind(ctx)()
+		logger.Info("merged")
+	}()
+	logger.Info("restored")
+	log.MdcClear()
+
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[requestId=r2, userId=u2] --- bound\n"+
+		"[requestId=r2, tenantId=t2, userId=u2] --- merged\n"+
+		"[tenantId=t2] --- restored\n", content)
+}
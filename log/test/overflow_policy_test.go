@@ -0,0 +1,128 @@
+package main
+
+import (
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writer blocks until released, so that events are piled up in the queue
+type blockingWriter struct {
+	*log.StringWriter
+	started     chan struct{}
+	release     chan struct{}
+	startedOnce sync.Once
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		StringWriter: log.NewStringWriter(buffer.NewRecycleByteBuffer(1024)),
+		started:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+}
+
+func (writer *blockingWriter) Write(p []byte) (int, error) {
+	writer.startedOnce.Do(func() {
+		close(writer.started)
+	})
+	<-writer.release
+	return writer.StringWriter.Write(p)
+}
+
+func TestOverflowPolicyDropNewest(t *testing.T) {
+	content, dropped := appendWithOverflowPolicy(log.OverflowPolicyDropNewest, 2, 0, log.InfoLevel)
+	utils.AssertTrue(content == "1\n2\n3\n", content)
+	utils.AssertTrue(dropped == 2, "test")
+}
+
+func TestOverflowPolicyDropOldest(t *testing.T) {
+	content, dropped := appendWithOverflowPolicy(log.OverflowPolicyDropOldest, 2, 0, log.InfoLevel)
+	utils.AssertTrue(content == "1\n4\n5\n", content)
+	utils.AssertTrue(dropped == 2, "test")
+}
+
+func TestOverflowPolicyDiscardBelowWarn(t *testing.T) {
+	content, dropped := appendWithOverflowPolicy(log.OverflowPolicyDiscardBelowWarn, 4, 2, log.WarnLevel)
+	utils.AssertTrue(content == "1\n2\n3\n5\n", content)
+	utils.AssertTrue(dropped == 1, "test")
+}
+
+func TestOverflowPolicyDiscardBelowWarnWithTinyQueue(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout:         "%m%n",
+		Writer:         writer,
+		QueueSize:      1,
+		OverflowPolicy: log.OverflowPolicyDiscardBelowWarn,
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("tinyQueue", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger.Info("1")
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "1\n", content)
+	utils.AssertTrue(writerAppender.GetDroppedCount() == 0, "test")
+}
+
+func TestInvalidOverflowPolicy(t *testing.T) {
+	_, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout:         "%m%n",
+		Writer:         newBlockingWriter(),
+		OverflowPolicy: "unknown",
+	})
+	utils.AssertTrue(err != nil, "test")
+
+	_, err = log.NewWriterAppender(&log.AppenderConfig{
+		Layout:              "%m%n",
+		Writer:              newBlockingWriter(),
+		QueueSize:           2,
+		OverflowPolicy:      log.OverflowPolicyDiscardBelowWarn,
+		DiscardingThreshold: 3,
+	})
+	utils.AssertTrue(err != nil, "test")
+
+	_, err = log.NewWriterAppender(&log.AppenderConfig{
+		Layout:              "%m%n",
+		Writer:              newBlockingWriter(),
+		QueueSize:           4,
+		OverflowPolicy:      log.OverflowPolicyDropOldest,
+		DiscardingThreshold: 2,
+	})
+	utils.AssertTrue(err != nil, "test")
+}
+
+// append 1 and wait until it is being written, then append 2 to 5, the last one is appended with lastLevel
+func appendWithOverflowPolicy(overflowPolicy string, queueSize int, discardingThreshold int, lastLevel int) (string, int64) {
+	writer := newBlockingWriter()
+	writerAppender, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout:              "%m%n",
+		Writer:              writer,
+		QueueSize:           queueSize,
+		OverflowPolicy:      overflowPolicy,
+		DiscardingThreshold: discardingThreshold,
+	})
+	utils.AssertNil(err, "test")
+
+	logger := log.NewLogger("overflow", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger.Info("1")
+	<-writer.started
+	logger.Info("2")
+	logger.Info("3")
+	logger.Info("4")
+	if lastLevel == log.WarnLevel {
+		logger.Warn("5")
+	} else {
+		logger.Info("5")
+	}
+
+	close(writer.release)
+	time.Sleep(time.Millisecond * 10)
+	return writer.ReadString(), writerAppender.GetDroppedCount()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writer never returns, so that the events written by it are lost
+type blockingWriter struct {
+	*log.StringWriter
+}
+
+func (writer *blockingWriter) Write(p []byte) (int, error) {
+	select {}
+}
+
+// set once shutdown is tested
+var isShutdown bool
+
+// shutdown can only be called once in a process, so it is tested in a separate package,
+// and skipped in the later runs, like `go test -count=2`
+func TestShutdown(t *testing.T) {
+	if isShutdown {
+		t.Skip("shutdown can only be tested once in a process")
+	}
+	isShutdown = true
+
+	directory := "/tmp/gtools/shutdown"
+	_ = os.RemoveAll(directory)
+
+	fileAppender, err := log.NewFileAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		FileRollingPolicy: &log.RollingPolicy{
+			Directory:       directory,
+			FileName:        "shutdown",
+			TimeGranularity: log.TimeGranularityHour,
+			MaxHistory:      1,
+			MaxFileSize:     1024 * 1024,
+		},
+	})
+	utils.AssertNil(err, "test")
+
+	writerAppender, err := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "%m%n",
+		Writer: &blockingWriter{StringWriter: log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))},
+	})
+	utils.AssertNil(err, "test")
+
+	log.NewLogger("shutdown", log.InfoLevel, false, []log.Appender{fileAppender})
+	log.NewLogger("lost", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	for i := 0; i < 1000; i += 1 {
+		log.GetLogger("shutdown").Info("line {}", i)
+	}
+	for i := 0; i < 3; i += 1 {
+		log.GetLogger("lost").Info("line {}", i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err = log.Shutdown(ctx)
+	utils.AssertTrue(err != nil && err.Error() == "shutdown timeout, 3 events are lost", "test")
+
+	// events are ignored after shutdown
+	log.GetLogger("shutdown").Info("after shutdown")
+
+	content, err := ioutil.ReadFile(directory + "/shutdown.log")
+	utils.AssertNil(err, "test")
+	utils.AssertTrue(strings.Count(string(content), "\n") == 1000, "test")
+	utils.AssertFalse(strings.Contains(string(content), "after shutdown"), "test")
+
+	// configuration is not applied after shutdown
+	path := directory + "/log.yaml"
+	utils.AssertNil(ioutil.WriteFile(path, []byte(`
+scan: true
+scanPeriod: 10ms
+appenders:
+  file:
+    type: file
+    layout: "%m%n"
+    rollingPolicy:
+      directory: `+directory+`
+      fileName: configured
+      maxHistory: 1
+      maxFileSize: 1MB
+root:
+  level: INFO
+  appenders: [file]
+`), 0644), "test")
+	err = log.ConfigureFromFile(path)
+	utils.AssertTrue(err != nil && err.Error() == "log is already shut down", "test")
+
+	_, err = os.Stat(directory + "/configured.log")
+	utils.AssertTrue(os.IsNotExist(err), "test")
+}
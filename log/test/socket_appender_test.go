@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSocketAppender(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer listener.Close()
+
+	socketAppender, err := log.NewSocketAppender(&log.AppenderConfig{
+		Socket: &log.SocketConfig{
+			Address: listener.Addr().String(),
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer socketAppender.Destroy()
+
+	logger := log.NewLogger("socketSender", log.InfoLevel, false, []log.Appender{socketAppender})
+	logger.With("user", 1).Error("hello {}", "world", errors.New("failed"))
+
+	conn, err := listener.Accept()
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	utils.AssertNil(err, "test")
+
+	var event map[string]interface{}
+	utils.AssertNil(json.Unmarshal([]byte(line), &event), line)
+	utils.AssertTrue(event["name"] == "socketSender", line)
+	utils.AssertTrue(event["level"] == float64(log.ErrorLevel), line)
+	utils.AssertTrue(event["message"] == "hello {}", line)
+	utils.AssertTrue(event["formattedMessage"] == "hello world", line)
+	utils.AssertTrue(event["throwable"] == "*errors.errorString: failed\n", line)
+	utils.AssertTrue(strings.Contains(line, `"fields":[{"key":"user","value":"1"}]`), line)
+}
+
+func TestSocketAppenderBacklog(t *testing.T) {
+	// reserve a port which is not listened yet
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	socketAppender, err := log.NewSocketAppender(&log.AppenderConfig{
+		Socket: &log.SocketConfig{
+			Address:           address,
+			ReconnectInterval: time.Millisecond * 10,
+			BacklogSize:       3,
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer socketAppender.Destroy()
+
+	logger := log.NewLogger("socketBacklog", log.InfoLevel, false, []log.Appender{socketAppender})
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		logger.Info(message)
+	}
+	time.Sleep(time.Millisecond * 10)
+	utils.AssertTrue(socketAppender.GetDroppedCount() == 2, "test")
+
+	// the latest events are sent once the receiver is up
+	listener, err = net.Listen("tcp", address)
+	utils.AssertNil(err, "test")
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	for _, expected := range []string{"3", "4", "5"} {
+		line, err := reader.ReadString('\n')
+		utils.AssertNil(err, "test")
+		utils.AssertTrue(strings.Contains(line, `"formattedMessage":"`+expected+`"`), line)
+	}
+}
+
+func TestSocketReceiver(t *testing.T) {
+	receiver, err := log.NewSocketReceiver("127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer receiver.Close()
+
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p] [%c] [%L] %m [%fields]%n",
+		Writer: writer,
+	})
+	log.NewLogger("com.receiver", log.WarnLevel, false, []log.Appender{writerAppender})
+
+	socketAppender, err := log.NewSocketAppender(&log.AppenderConfig{
+		Socket: &log.SocketConfig{
+			Address: receiver.Addr().String(),
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer socketAppender.Destroy()
+
+	// events of another process are simulated by appending to socket appender directly,
+	// otherwise they would be sent again by the same logger of this process
+	socketAppender.DoAppend(&log.LoggingEvent{
+		Name:      "com.receiver.child",
+		Level:     log.InfoLevel,
+		Timestamp: time.Now(),
+		Message:   "you cannot see this",
+	})
+	socketAppender.DoAppend(&log.LoggingEvent{
+		Name:      "com.receiver.child",
+		Level:     log.WarnLevel,
+		Timestamp: time.Now(),
+		File:      "/src/main.go",
+		Line:      34,
+		Message:   "hello {}",
+		Values:    []interface{}{"world"},
+		Fields:    []log.Field{log.NewField("user", 1)},
+		Throwable: errors.New("failed"),
+	})
+	time.Sleep(time.Millisecond * 50)
+
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[WARN] [com.receiver.child] [main.go:34] hello world [user=1]\n"+
+		"*errors.errorString: failed\n", content)
+}
+
+func TestSocketReceiverRootNames(t *testing.T) {
+	receiver, err := log.NewSocketReceiver("127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer receiver.Close()
+
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p] [%c] %m%n",
+		Writer: writer,
+	})
+	log.NewLogger(log.Root, log.InfoLevel, false, []log.Appender{writerAppender})
+
+	socketAppender, err := log.NewSocketAppender(&log.AppenderConfig{
+		Socket: &log.SocketConfig{
+			Address: receiver.Addr().String(),
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer socketAppender.Destroy()
+
+	// remote events named like root are appended to the local root, which is never replaced
+	for _, name := range []string{"root", "ROOT", ""} {
+		socketAppender.DoAppend(&log.LoggingEvent{
+			Name:      name,
+			Level:     log.InfoLevel,
+			Timestamp: time.Now(),
+			Message:   "remote " + name,
+		})
+	}
+	time.Sleep(time.Millisecond * 50)
+	log.GetLogger(log.Root).Info("local")
+	time.Sleep(time.Millisecond * 10)
+
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[INFO] [root] remote root\n"+
+		"[INFO] [ROOT] remote ROOT\n"+
+		"[INFO] [] remote \n"+
+		"[INFO] [ROOT] local\n", content)
+	utils.AssertTrue(log.GetLevel(log.Root) == log.InfoLevel, "test")
+}
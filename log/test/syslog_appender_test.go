@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogUdpRFC5424(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+
+	syslogAppender, err := log.NewSyslogAppender(&log.AppenderConfig{
+		Syslog: &log.SyslogConfig{
+			Network:  log.SyslogNetworkUdp,
+			Address:  conn.LocalAddr().String(),
+			Facility: "local0",
+			AppName:  "gtools",
+			Hostname: "host01",
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer syslogAppender.Destroy()
+
+	logger := log.NewLogger("syslogUdp", log.InfoLevel, false, []log.Appender{syslogAppender})
+	ctx := log.ContextWithTraceId(context.Background(), "abc")
+	logger.Ctx(ctx).With("user", "a\"b]").Warn("hello {}", "world")
+
+	message := readPacket(conn)
+	pattern := regexp.MustCompile(`^<132>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}(Z|[+-]\d{2}:\d{2}) host01 gtools ` +
+		strconv.Itoa(os.Getpid()) + ` - \[fields@32473 user="a\\"b\\]" traceId="abc"\] hello world$`)
+	utils.AssertTrue(pattern.MatchString(message), message)
+
+	logger.Info("no fields")
+	message = readPacket(conn)
+	utils.AssertTrue(strings.HasPrefix(message, "<134>1 "), message)
+	utils.AssertTrue(strings.HasSuffix(message, " - - no fields"), message)
+}
+
+func TestSyslogUdpRFC3164(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+
+	syslogAppender, err := log.NewSyslogAppender(&log.AppenderConfig{
+		Layout: "[%p] %m%n",
+		Syslog: &log.SyslogConfig{
+			Network:  log.SyslogNetworkUdp,
+			Address:  conn.LocalAddr().String(),
+			Format:   log.SyslogFormatRFC3164,
+			AppName:  "gtools",
+			Hostname: "host01",
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer syslogAppender.Destroy()
+
+	logger := log.NewLogger("syslog3164", log.TraceLevel, false, []log.Appender{syslogAppender})
+	logger.Trace("hello")
+
+	message := readPacket(conn)
+	pattern := regexp.MustCompile(`^<15>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} host01 gtools\[` +
+		strconv.Itoa(os.Getpid()) + `\]: \[TRACE\] hello$`)
+	utils.AssertTrue(pattern.MatchString(message), message)
+}
+
+func TestSyslogTcpReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer listener.Close()
+
+	syslogAppender, err := log.NewSyslogAppender(&log.AppenderConfig{
+		Syslog: &log.SyslogConfig{
+			Network:           log.SyslogNetworkTcp,
+			Address:           listener.Addr().String(),
+			ReconnectInterval: time.Millisecond * 10,
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer syslogAppender.Destroy()
+
+	logger := log.NewLogger("syslogTcp", log.InfoLevel, false, []log.Appender{syslogAppender})
+	logger.Info("first")
+
+	conn, err := listener.Accept()
+	utils.AssertNil(err, "test")
+	message := readOctetCounted(bufio.NewReader(conn))
+	utils.AssertTrue(strings.HasSuffix(message, " first"), message)
+
+	// messages are sent through a new connection after the server closes the previous one
+	_ = conn.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond * 10):
+				logger.Info("second")
+			}
+		}
+	}()
+
+	conn, err = listener.Accept()
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+	message = readOctetCounted(bufio.NewReader(conn))
+	utils.AssertTrue(strings.HasSuffix(message, " second"), message)
+}
+
+func TestSyslogUnix(t *testing.T) {
+	path := fmt.Sprintf("/tmp/gtools/syslog-%d.sock", os.Getpid())
+	_ = os.MkdirAll("/tmp/gtools", os.ModePerm)
+	_ = os.Remove(path)
+
+	conn, err := net.ListenPacket("unixgram", path)
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+	defer os.Remove(path)
+
+	syslogAppender, err := log.NewSyslogAppender(&log.AppenderConfig{
+		Syslog: &log.SyslogConfig{
+			Network: log.SyslogNetworkUnix,
+			Address: path,
+		},
+	})
+	utils.AssertNil(err, "test")
+	defer syslogAppender.Destroy()
+
+	logger := log.NewLogger("syslogUnix", log.InfoLevel, false, []log.Appender{syslogAppender})
+	logger.Error("hello")
+
+	message := readPacket(conn)
+	utils.AssertTrue(strings.HasPrefix(message, "<11>1 "), message)
+	utils.AssertTrue(strings.HasSuffix(message, " hello"), message)
+}
+
+func TestInvalidSyslogConfig(t *testing.T) {
+	_, err := log.NewSyslogAppender(&log.AppenderConfig{
+		Syslog: &log.SyslogConfig{
+			Network:  log.SyslogNetworkUdp,
+			Address:  "127.0.0.1:514",
+			Facility: "unknown",
+		},
+	})
+	utils.AssertTrue(err != nil && err.Error() == "unsupported syslog facility 'unknown'", "test")
+
+	_, err = log.NewSyslogAppender(&log.AppenderConfig{
+		Syslog: &log.SyslogConfig{
+			Network: "icmp",
+			Address: "127.0.0.1:514",
+		},
+	})
+	utils.AssertTrue(err != nil && err.Error() == "unsupported syslog network 'icmp'", "test")
+}
+
+func readPacket(conn net.PacketConn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buffer := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buffer)
+	utils.AssertNil(err, "test")
+	return string(buffer[:n])
+}
+
+// read message framed like `11 <14>1 - ...`
+func readOctetCounted(reader *bufio.Reader) string {
+	lengthText, err := reader.ReadString(' ')
+	utils.AssertNil(err, "test")
+	length, err := strconv.Atoi(strings.TrimSpace(lengthText))
+	utils.AssertNil(err, "test")
+
+	buffer := make([]byte, length)
+	_, err = io.ReadFull(reader, buffer)
+	utils.AssertNil(err, "test")
+	return string(buffer)
+}
+
+func TestConfigureSyslogAppender(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	utils.AssertNil(err, "test")
+	defer conn.Close()
+
+	resetConfigDirectory()
+	path := writeConfigFile("log.yaml", fmt.Sprintf(`
+appenders:
+  syslog:
+    type: syslog
+    syslog:
+      network: udp
+      address: %s
+      format: rfc3164
+      facility: local7
+      appName: gtools
+      hostname: host01
+      reconnectInterval: 5s
+loggers:
+  com.syslog:
+    level: INFO
+    appenders: [syslog]
+`, conn.LocalAddr().String()))
+	utils.AssertNil(log.ConfigureFromFile(path), "test")
+
+	log.GetLogger("com.syslog").Info("hello")
+
+	message := readPacket(conn)
+	utils.AssertTrue(strings.HasPrefix(message, "<190>"), message)
+	utils.AssertTrue(strings.HasSuffix(message, " host01 gtools["+strconv.Itoa(os.Getpid())+"]: hello"), message)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/liuyehcf/common-gtools/buffer"
+	"github.com/liuyehcf/common-gtools/log"
+	"github.com/liuyehcf/common-gtools/utils"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stackError struct {
+	message string
+	stack   []uintptr
+}
+
+func newStackError(message string) *stackError {
+	stack := make([]uintptr, 32)
+	return &stackError{
+		message: message,
+		stack:   stack[:runtime.Callers(2, stack)],
+	}
+}
+
+func (err *stackError) Error() string {
+	return err.message
+}
+
+func (err *stackError) StackTrace() []uintptr {
+	return err.stack
+}
+
+func TestThrowable(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p]-[%L] --- %m%n%ex",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("throwable", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("query failed: %w", cause)
+
+	var content string
+
+	logger.Info("no throwable")
+	logger.Error("consumed by placeholder, {}", cause)
+	logger.Error("query {} failed", "users", err)
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[INFO]-[throwable_test.go:50] --- no throwable\n"+
+		"[ERROR]-[throwable_test.go:51] --- consumed by placeholder, connection refused\n"+
+		"[ERROR]-[throwable_test.go:52] --- query users failed\n"+
+		"*fmt.wrapError: query failed: connection refused\n"+
+		"Caused by: *errors.errorString: connection refused\n", content)
+
+	logger.Error("with stack trace", newStackError("stack error"))
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(strings.HasPrefix(content, "[ERROR]-[throwable_test.go:61] --- with stack trace\n"+
+		"*main.stackError: stack error\n"+
+		"\tat github.com/liuyehcf/common-gtools/log/test.TestThrowable("), content)
+	utils.AssertTrue(strings.Contains(content, "throwable_test.go:61)\n"), content)
+}
+
+func TestImplicitThrowable(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p] --- %m%n",
+		Writer: writer,
+	})
+	noThrowableWriter := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	noThrowableAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p] --- %m%nopex%n",
+		Writer: noThrowableWriter,
+	})
+
+	logger := log.NewLogger("implicitThrowable", log.InfoLevel, false, []log.Appender{writerAppender, noThrowableAppender})
+
+	logger.Warn("something wrong", errors.New("error"))
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertTrue(content == "[WARN] --- something wrong\n*errors.errorString: error\n", content)
+	content = noThrowableWriter.ReadString()
+	utils.AssertTrue(content == "[WARN] --- something wrong\n", content)
+}
+
+func TestThrowableJsonEncoder(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(1024))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Encoder: log.EncoderJson,
+		Writer:  writer,
+	})
+
+	logger := log.NewLogger("throwableJson", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	logger.Error("query failed", errors.New("connection refused"))
+	time.Sleep(time.Millisecond * 10)
+	content := writer.ReadString()
+	utils.AssertFalse(strings.Contains(content, `"arguments"`), content)
+	utils.AssertTrue(strings.Contains(content, `"throwable":"*errors.errorString: connection refused\n"}`), content)
+}
+
+// error whose cause is itself
+type cyclicError struct {
+}
+
+func (err *cyclicError) Error() string {
+	return "cyclic"
+}
+
+func (err *cyclicError) Unwrap() error {
+	return err
+}
+
+// error whose cause is always a new one
+type endlessError struct {
+	depth int
+}
+
+func (err *endlessError) Error() string {
+	return fmt.Sprintf("depth %d", err.depth)
+}
+
+func (err *endlessError) Unwrap() error {
+	return &endlessError{depth: err.depth + 1}
+}
+
+// error wrapping several ones, like errors created by errors.Join
+type joinedError struct {
+	errs []error
+}
+
+func (err *joinedError) Error() string {
+	return "joined"
+}
+
+func (err *joinedError) Unwrap() []error {
+	return err.errs
+}
+
+func TestThrowableCauses(t *testing.T) {
+	writer := log.NewStringWriter(buffer.NewRecycleByteBuffer(8192))
+	writerAppender, _ := log.NewWriterAppender(&log.AppenderConfig{
+		Layout: "[%p] --- %m%n%ex",
+		Writer: writer,
+	})
+
+	logger := log.NewLogger("throwableCauses", log.InfoLevel, false, []log.Appender{writerAppender})
+
+	var content string
+
+	logger.Error("cyclic", &cyclicError{})
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[ERROR] --- cyclic\n"+
+		"*main.cyclicError: cyclic\n"+
+		"Caused by: [CIRCULAR REFERENCE: *main.cyclicError: cyclic]\n", content)
+
+	logger.Error("endless", &endlessError{})
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(strings.HasPrefix(content, "[ERROR] --- endless\n*main.endlessError: depth 0\n"), content)
+	utils.AssertTrue(strings.HasSuffix(content, "Caused by: *main.endlessError: depth 63\nCaused by: ...\n"), content)
+
+	cause := errors.New("connection refused")
+	joined := &joinedError{errs: []error{
+		fmt.Errorf("query failed: %w", cause),
+		&joinedError{errs: []error{errors.New("timeout")}},
+	}}
+	logger.Error("joined", fmt.Errorf("request failed: %w", joined))
+	time.Sleep(time.Millisecond * 10)
+	content = writer.ReadString()
+	utils.AssertTrue(content == "[ERROR] --- joined\n"+
+		"*fmt.wrapError: request failed: joined\n"+
+		"Caused by: *main.joinedError: joined\n"+
+		"\tCaused by: *fmt.wrapError: query failed: connection refused\n"+
+		"\tCaused by: *errors.errorString: connection refused\n"+
+		"\tCaused by: *main.joinedError: joined\n"+
+		"\t\tCaused by: *errors.errorString: timeout\n", content)
+}
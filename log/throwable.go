@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	stackTraceMethod = "StackTrace"
+	causedByPrefix   = "Caused by: "
+
+	// causes beyond it are omitted, in case that Unwrap keeps returning new errors
+	maxThrowableCauses = 64
+)
+
+// implemented by errors wrapping several ones, such as errors created by errors.Join
+type multipleCauses interface {
+	Unwrap() []error
+}
+
+// format the throwable with its cause chain unwrapped by errors.Unwrap, like java
+//
+//	*main.MyError: query failed
+//		at main.query(/src/main.go:34)
+//	Caused by: *errors.errorString: connection refused
+//
+// stack trace is printed if the error has a method `StackTrace()`, such as errors created by github.com/pkg/errors
+// causes of an error wrapping several ones, such as errors created by errors.Join, are indented below it
+func formatThrowable(throwable error) string {
+	// already formatted by the remote process
+	if remote, ok := throwable.(*remoteThrowable); ok {
+		return remote.formatted
+	}
+
+	buffer := bytes.Buffer{}
+	visited := make([]error, 0)
+	writeThrowable(&buffer, throwable, emptyString, emptyString, &visited)
+
+	return buffer.String()
+}
+
+// errors which are already written are marked as circular reference, so that a cause referring to its wrapper
+// does not loop forever
+func writeThrowable(buffer *bytes.Buffer, err error, prefix string, indent string, visited *[]error) {
+	for ; err != nil; {
+		if len(*visited) >= maxThrowableCauses {
+			buffer.WriteString(indent + prefix + "...\n")
+			return
+		}
+		if containsError(*visited, err) {
+			buffer.WriteString(fmt.Sprintf("%s%s[CIRCULAR REFERENCE: %T: %s]\n", indent, prefix, err, err.Error()))
+			return
+		}
+		*visited = append(*visited, err)
+
+		buffer.WriteString(fmt.Sprintf("%s%s%T: %s\n", indent, prefix, err, err.Error()))
+		writeStackTrace(buffer, indent, err)
+
+		if multiple, ok := err.(multipleCauses); ok {
+			for _, cause := range multiple.Unwrap() {
+				writeThrowable(buffer, cause, causedByPrefix, indent+"\t", visited)
+			}
+			return
+		}
+
+		prefix = causedByPrefix
+		err = errors.Unwrap(err)
+	}
+}
+
+func containsError(errs []error, target error) bool {
+	for _, err := range errs {
+		if isSameError(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errors of non-comparable types, like structs containing slices, make comparing panic, and are taken as different
+func isSameError(left error, right error) (isSame bool) {
+	defer func() {
+		if recover() != nil {
+			isSame = false
+		}
+	}()
+	return left == right
+}
+
+// the return value of `StackTrace()` is detected by reflection, so that no dependency is introduced
+// a slice of program counters is resolved to frames, otherwise it is formatted with `%+v`
+func writeStackTrace(buffer *bytes.Buffer, indent string, err error) {
+	method := reflect.ValueOf(err).MethodByName(stackTraceMethod)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return
+	}
+
+	stackTrace := method.Call(nil)[0]
+
+	if stackTrace.Kind() == reflect.Slice && stackTrace.Type().Elem().Kind() == reflect.Uintptr {
+		pcs := make([]uintptr, stackTrace.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(stackTrace.Index(i).Uint())
+		}
+
+		frames := runtime.CallersFrames(pcs)
+		for {
+			frame, more := frames.Next()
+			if frame.PC != 0 {
+				buffer.WriteString(indent + "\tat " + frame.Function + "(" + frame.File + ":" + strconv.Itoa(frame.Line) + ")\n")
+			}
+			if !more {
+				break
+			}
+		}
+		return
+	}
+
+	for _, line := range strings.Split(fmt.Sprintf("%+v", stackTrace.Interface()), "\n") {
+		if strings.TrimSpace(line) == emptyString {
+			continue
+		}
+		buffer.WriteString(indent + "\t" + strings.TrimSpace(line) + "\n")
+	}
+}
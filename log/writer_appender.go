@@ -1,9 +1,10 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"io"
-	"sync"
+	"os"
 )
 
 type writerAppender struct {
@@ -17,57 +18,104 @@ func NewWriterAppender(config *AppenderConfig) (*writerAppender, error) {
 		return nil, errors.New("write is required for writer appender")
 	}
 
-	encoder, err := newPatternEncoder(config.Layout)
+	encoder, err := newEncoder(config, isTerminal(config.Writer))
+	if err != nil {
+		return nil, err
+	}
+	abstractAppender, err := newAbstractAppender(config, encoder)
 	if err != nil {
 		return nil, err
 	}
 	appender := &writerAppender{
-		abstractAppender: abstractAppender{
-			encoder: encoder,
-			filters: config.Filters,
-			lock:    new(sync.Mutex),
-			queue:   make(chan []byte, 1024),
-		},
-		writer:    config.Writer,
-		needClose: config.NeedClose,
+		abstractAppender: abstractAppender,
+		writer:           config.Writer,
+		needClose:        config.NeedClose,
 	}
 
-	go appender.onEventLoop()
+	err = appender.Start()
+	if err != nil {
+		return nil, err
+	}
 
 	return appender, nil
 }
 
-func (appender *writerAppender) Destroy() {
-	lock.Lock()
-	defer lock.Unlock()
-	appender.isDestroyed = true
-	executeIgnorePanic(func() {
-		close(appender.queue)
-	})
-	executeIgnorePanic(func() {
-		if appender.needClose {
-			_ = appender.writer.Close()
-		}
+func (appender *writerAppender) Start() error {
+	return appender.start(func() {
+		go appender.onEventLoop()
 	})
 }
 
+// stop accepting events, the queued events are still written, and the writer is closed after that if necessary
+func (appender *writerAppender) Stop() {
+	appender.stop(appender.onEventLoop)
+}
+
+func (appender *writerAppender) Destroy() {
+	appender.Stop()
+}
+
+func (appender *writerAppender) flush(ctx context.Context) bool {
+	if !appender.waitDrained(ctx) {
+		return false
+	}
+	appender.sync()
+	return true
+}
+
 func (appender *writerAppender) onEventLoop() {
+	defer close(appender.stopped)
+	defer appender.closeWriter()
 	defer func() {
 		recover()
 	}()
 
-	var content []byte
-	var ok bool
-	for !appender.isDestroyed {
-		if content, ok = <-appender.queue; !ok {
-			// channel is closed
-			break
-
-		}
+	// the queued events are drained until the channel is closed
+	for content := range appender.queue {
 		appender.write(content)
+		appender.onWritten()
 	}
 }
 
+// called after the event loop exits
+func (appender *writerAppender) closeWriter() {
+	appender.sync()
+	if appender.needClose {
+		executeIgnorePanic(func() {
+			_ = appender.writer.Close()
+		})
+	}
+}
+
+// sync the writer if it supports, like *os.File
+func (appender *writerAppender) sync() {
+	syncer, ok := appender.writer.(interface {
+		Sync() error
+	})
+	if !ok {
+		return
+	}
+
+	appender.lock.Lock()
+	defer appender.lock.Unlock()
+	_ = syncer.Sync()
+}
+
+// colour is only enabled when writing to terminal, so that redirected output stays plain
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func (appender *writerAppender) write(bytes []byte) {
 	appender.lock.Lock()
 	defer appender.lock.Unlock()